@@ -0,0 +1,663 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/eefret/gator/external/rss"
+	"github.com/eefret/gator/internal/article"
+	"github.com/eefret/gator/internal/daemon"
+	"github.com/eefret/gator/internal/database"
+	"github.com/eefret/gator/internal/htmltext"
+	"github.com/eefret/gator/internal/output"
+	"github.com/google/uuid"
+)
+
+// scrapeTimeout bounds downloading and storing a single feed.
+const scrapeTimeout = 20 * time.Second
+
+// articleTimeout bounds the full content downloads that follow a scrape, so
+// they neither eat into nor are cut short by the feed's own budget.
+const articleTimeout = 60 * time.Second
+
+// lookupTimeout bounds finding the feed to scrape.
+const lookupTimeout = 10 * time.Second
+
+// fetchTimeout bounds a whole fetch: the lookup, the scrape and its articles.
+const fetchTimeout = lookupTimeout + scrapeTimeout + articleTimeout
+
+// fetchLogPruneTimeout and postPruneTimeout bound the pruning that may
+// follow the fetch in a cycle.
+const (
+	fetchLogPruneTimeout = 30 * time.Second
+	postPruneTimeout     = 5 * time.Minute
+)
+
+// fetchNowTimeout is how long fetch-now waits for its reply: the cycle that
+// may be in progress, pruning included, then the requested fetch.
+const fetchNowTimeout = fetchTimeout + fetchLogPruneTimeout + postPruneTimeout + fetchTimeout + 5*time.Second
+
+// aggregator fetches feeds on a ticker and answers control requests from
+// `agg status` and `fetch-now` while it runs.
+type aggregator struct {
+	db       database.Store
+	interval time.Duration
+	fetchNow chan fetchRequest
+	metrics  *aggMetrics
+	webhooks *webhookQueue
+
+	// fetchLogRetention is how long fetch history is kept; lastPrune is when
+	// it was last pruned.
+	fetchLogRetention time.Duration
+	lastPrune         time.Time
+
+	// postRetention is enforced every pruneInterval, archiving to
+	// postArchive when it is set. A zero interval leaves it to `prune`.
+	postRetention retentionPolicy
+	postArchive   string
+	pruneInterval time.Duration
+	lastPostPrune time.Time
+
+	mu     sync.Mutex
+	status daemon.Status
+}
+
+// fetchRequest asks the aggregator loop for an immediate fetch of url, or of
+// the next due feed when url is empty.
+type fetchRequest struct {
+	url  string
+	done chan error
+}
+
+func handleAgg(s *State, cmd Command) error {
+	if len(cmd.Arguments) == 0 {
+		return fmt.Errorf("Agg requires an interval or one of start, stop, status")
+	}
+
+	switch cmd.Arguments[0] {
+	case "start":
+		if len(cmd.Arguments) != 2 {
+			return fmt.Errorf("Agg start requires an interval. example agg start 1m")
+		}
+		return startAggDaemon(cmd.Arguments[1])
+	case "stop":
+		return stopAggDaemon()
+	case "status":
+		return printAggStatus(s)
+	}
+
+	if len(cmd.Arguments) != 1 {
+		return fmt.Errorf("Agg just requires one argument")
+	}
+
+	timeBetweenRequests, err := time.ParseDuration(cmd.Arguments[0])
+	if err != nil {
+		return fmt.Errorf("Error parsing duration: %v", err)
+	}
+
+	retention, err := s.Config.FetchLogRetentionDuration()
+	if err != nil {
+		return fmt.Errorf("Error parsing fetch_log_retention: %v", err)
+	}
+
+	pruneInterval, err := s.Config.PruneIntervalDuration()
+	if err != nil {
+		return fmt.Errorf("Error parsing prune_interval: %v", err)
+	}
+
+	paths, err := daemon.DefaultPaths()
+	if err != nil {
+		return fmt.Errorf("Error locating state directory: %v", err)
+	}
+
+	lock, err := daemon.AcquireLock(paths.PidFile)
+	if err != nil {
+		return fmt.Errorf("Error acquiring aggregator lock: %v", err)
+	}
+	defer lock.Release()
+
+	// Cancelled on SIGINT/SIGTERM so in-flight fetches and inserts stop
+	// through their contexts instead of the process dying mid-write.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	webhooks := newWebhookQueue(ctx, s.db, webhookQueueSize)
+	defer webhooks.close()
+
+	agg := &aggregator{
+		db:       s.db,
+		interval: timeBetweenRequests,
+		fetchNow: make(chan fetchRequest),
+		metrics:  newAggMetrics(s.db, timeBetweenRequests),
+		webhooks: webhooks,
+
+		fetchLogRetention: retention,
+		postRetention:     defaultRetention(s.Config),
+		postArchive:       s.Config.RetentionArchive,
+		pruneInterval:     pruneInterval,
+		status: daemon.Status{
+			PID:      os.Getpid(),
+			Started:  time.Now(),
+			Interval: timeBetweenRequests.String(),
+		},
+	}
+
+	listener, err := daemon.Listen(paths.Socket)
+	if err != nil {
+		return fmt.Errorf("Error opening control socket: %v", err)
+	}
+	defer os.Remove(paths.Socket)
+
+	go func() {
+		if err := daemon.Serve(ctx, listener, agg.handleControl); err != nil {
+			slog.Error("Error serving control socket", "error", err)
+		}
+	}()
+
+	if s.Config.MetricsAddr != "" {
+		server := &http.Server{
+			Addr:    s.Config.MetricsAddr,
+			Handler: metricsMux(agg.metrics),
+		}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("Error serving metrics", "addr", s.Config.MetricsAddr, "error", err)
+			}
+		}()
+		defer server.Close()
+
+		slog.Info("Serving metrics", "url", "http://"+s.Config.MetricsAddr+"/metrics")
+	}
+
+	slog.Info("Collecting feeds", "interval", timeBetweenRequests.String(), "pid", os.Getpid())
+
+	agg.run(ctx)
+	logAggSummary(agg.snapshot())
+
+	return nil
+}
+
+// run fetches a feed on every tick until ctx is cancelled. Fetch requests are
+// handled on the same goroutine so two scrapes never overlap.
+func (a *aggregator) run(ctx context.Context) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	a.runCycle(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.runCycle(ctx)
+		case req := <-a.fetchNow:
+			req.done <- a.fetch(ctx, req.url)
+		}
+	}
+}
+
+// runCycle scrapes the next due feed, logged by fetch, and prunes the fetch
+// log and old posts when they are due.
+func (a *aggregator) runCycle(ctx context.Context) {
+	a.fetch(ctx, "")
+	a.maybePruneFetchLog(ctx)
+	a.maybePrunePosts(ctx)
+}
+
+// maybePruneFetchLog deletes expired fetch history at most once every
+// fetchLogPruneInterval.
+func (a *aggregator) maybePruneFetchLog(ctx context.Context) {
+	if ctx.Err() != nil || time.Since(a.lastPrune) < fetchLogPruneInterval {
+		return
+	}
+	a.lastPrune = time.Now()
+
+	pruneCtx, cancel := context.WithTimeout(ctx, fetchLogPruneTimeout)
+	defer cancel()
+
+	deleted, err := pruneFetchLog(pruneCtx, a.db, a.fetchLogRetention)
+	if err != nil {
+		slog.Warn("Error pruning fetch log", "error", err)
+		return
+	}
+	slog.Debug("Pruned fetch log", "deleted", deleted, "retention", a.fetchLogRetention.String())
+}
+
+// fetch scrapes url, or the next due feed when url is empty, recording the
+// outcome in the aggregator status.
+func (a *aggregator) fetch(ctx context.Context, url string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	started := time.Now()
+
+	var result scrapeResult
+	var err error
+	if url == "" {
+		result, err = scrapeFeeds(ctx, a.db, a.webhooks)
+	} else {
+		var feed database.Feed
+		lookupCtx, cancel := context.WithTimeout(ctx, lookupTimeout)
+		feed, err = a.db.GetFeedByURL(lookupCtx, url)
+		cancel()
+		if err != nil {
+			err = fmt.Errorf("Error getting feed: %v", err)
+		} else {
+			result, err = scrapeFeed(ctx, a.db, a.webhooks, feed)
+		}
+	}
+
+	if errors.Is(err, errNoFeedDue) {
+		slog.Debug("No feed is due for fetching")
+		return err
+	}
+
+	elapsed := time.Since(started)
+	if ctx.Err() != nil {
+		// Shutting down; the fetch was cancelled rather than failed.
+		slog.Info("Shutting down, cancelled in-flight fetch", feedAttrs(result.Feed)...)
+		return err
+	}
+
+	a.metrics.observe(result, err, elapsed)
+	logScrape(result, err, elapsed)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.status.Cycles++
+	a.status.LastCycle = time.Now()
+	switch {
+	case err == nil:
+		a.status.Fetched++
+	case ctx.Err() == nil:
+		a.status.Errors++
+		a.status.LastError = err.Error()
+	}
+
+	return err
+}
+
+func (a *aggregator) snapshot() daemon.Status {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.status
+}
+
+// handleControl answers requests arriving on the control socket.
+func (a *aggregator) handleControl(ctx context.Context, req daemon.Request) daemon.Response {
+	switch req.Command {
+	case "status":
+		status := a.snapshot()
+		return daemon.Response{Status: &status}
+	case "fetch":
+		fr := fetchRequest{url: req.URL, done: make(chan error, 1)}
+		select {
+		case a.fetchNow <- fr:
+		case <-ctx.Done():
+			return daemon.Response{Error: "aggregator is shutting down"}
+		}
+
+		if err := <-fr.done; err != nil {
+			return daemon.Response{Error: err.Error()}
+		}
+		if req.URL == "" {
+			return daemon.Response{Message: "Fetched the next due feed"}
+		}
+		return daemon.Response{Message: "Fetched " + req.URL}
+	}
+
+	return daemon.Response{Error: fmt.Sprintf("unknown command %q", req.Command)}
+}
+
+func logAggSummary(status daemon.Status) {
+	slog.Info("Aggregator stopped",
+		"uptime", time.Since(status.Started).Round(time.Second).String(),
+		"cycles", status.Cycles,
+		"fetched", status.Fetched,
+		"errors", status.Errors,
+	)
+}
+
+// logScrape records the outcome of a single scrape with the feed identity.
+func logScrape(result scrapeResult, err error, elapsed time.Duration) {
+	attrs := append(feedAttrs(result.Feed),
+		"status", fetchStatus(err),
+		"duration", elapsed.Round(time.Millisecond).String(),
+		"items", result.Items,
+		"new_posts", result.NewPosts,
+		"bytes", result.Bytes,
+	)
+
+	if err != nil {
+		slog.Error("Error scraping feed", append(attrs, "error", err)...)
+		return
+	}
+	slog.Info("Feed fetched", attrs...)
+}
+
+// feedAttrs identifies feed in log records, if one was selected.
+func feedAttrs(feed database.Feed) []any {
+	if feed.ID == uuid.Nil {
+		return nil
+	}
+	return []any{"feed_id", feed.ID, "feed_name", feed.Name, "url", feed.Url}
+}
+
+// startAggDaemon re-executes gator as a detached `agg <interval>` process
+// writing to the aggregator log file, and waits for it to answer on the
+// control socket.
+func startAggDaemon(interval string) error {
+	if _, err := time.ParseDuration(interval); err != nil {
+		return fmt.Errorf("Error parsing duration: %v", err)
+	}
+
+	paths, err := daemon.DefaultPaths()
+	if err != nil {
+		return fmt.Errorf("Error locating state directory: %v", err)
+	}
+
+	if pid, err := daemon.RunningPID(paths.PidFile); err == nil {
+		return fmt.Errorf("Aggregator is already running (pid %d)", pid)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("Error locating gator executable: %v", err)
+	}
+
+	logFile, err := os.OpenFile(paths.LogFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("Error opening log file: %v", err)
+	}
+	defer logFile.Close()
+
+	proc := exec.Command(exe, "agg", interval)
+	proc.Stdout = logFile
+	proc.Stderr = logFile
+	proc.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	if err := proc.Start(); err != nil {
+		return fmt.Errorf("Error starting aggregator: %v", err)
+	}
+	pid := proc.Process.Pid
+	proc.Process.Release()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := daemon.Call(paths.Socket, daemon.Request{Command: "status"}, time.Second); err == nil {
+			fmt.Printf("Aggregator started (pid %d), logging to %s\n", pid, paths.LogFile)
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return fmt.Errorf("Aggregator did not start, see %s", paths.LogFile)
+}
+
+// stopAggDaemon sends SIGTERM to the running aggregator and waits for it to
+// release its lock.
+func stopAggDaemon() error {
+	paths, err := daemon.DefaultPaths()
+	if err != nil {
+		return fmt.Errorf("Error locating state directory: %v", err)
+	}
+
+	pid, err := daemon.RunningPID(paths.PidFile)
+	if err != nil {
+		return fmt.Errorf("Error finding aggregator: %v", err)
+	}
+
+	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+		return fmt.Errorf("Error signalling aggregator: %v", err)
+	}
+
+	deadline := time.Now().Add(scrapeTimeout + 10*time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := daemon.RunningPID(paths.PidFile); errors.Is(err, daemon.ErrNotRunning) {
+			fmt.Printf("Aggregator (pid %d) stopped\n", pid)
+			return nil
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	return fmt.Errorf("Aggregator (pid %d) did not stop in time", pid)
+}
+
+func printAggStatus(s *State) error {
+	paths, err := daemon.DefaultPaths()
+	if err != nil {
+		return fmt.Errorf("Error locating state directory: %v", err)
+	}
+
+	resp, err := daemon.Call(paths.Socket, daemon.Request{Command: "status"}, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("Error getting aggregator status: %v", err)
+	}
+	status := *resp.Status
+
+	if s.Output != output.FormatText {
+		return output.Write(os.Stdout, s.Output, []daemon.Status{status})
+	}
+
+	fmt.Printf("Aggregator running (pid %d) since %s, every %s\n",
+		status.PID, status.Started.Format(time.RFC1123), status.Interval)
+	if !status.LastCycle.IsZero() {
+		fmt.Printf("Last cycle: %s\n", status.LastCycle.Format(time.RFC1123))
+	}
+	fmt.Printf("Cycles: %d | Fetched: %d | Errors: %d\n", status.Cycles, status.Fetched, status.Errors)
+	if status.LastError != "" {
+		fmt.Printf("Last error: %s\n", status.LastError)
+	}
+
+	return nil
+}
+
+func handleFetchNow(s *State, cmd Command) error {
+	if len(cmd.Arguments) > 1 {
+		return fmt.Errorf("Fetch-now accepts at most one feed url")
+	}
+
+	req := daemon.Request{Command: "fetch"}
+	if len(cmd.Arguments) == 1 {
+		req.URL = cmd.Arguments[0]
+	}
+
+	paths, err := daemon.DefaultPaths()
+	if err != nil {
+		return fmt.Errorf("Error locating state directory: %v", err)
+	}
+
+	resp, err := daemon.Call(paths.Socket, req, fetchNowTimeout)
+	if err != nil {
+		return fmt.Errorf("Error requesting fetch: %v", err)
+	}
+
+	fmt.Println(resp.Message)
+
+	return nil
+}
+
+// errNoFeedDue is returned by scrapeFeeds when every feed is disabled or was
+// fetched within its own interval.
+var errNoFeedDue = errors.New("No feed is due for fetching")
+
+func scrapeFeeds(ctx context.Context, db database.Store, webhooks *webhookQueue) (scrapeResult, error) {
+	lookupCtx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	next, err := db.GetNextFeedToFetch(lookupCtx)
+	cancel()
+	if errors.Is(err, sql.ErrNoRows) {
+		return scrapeResult{}, errNoFeedDue
+	}
+	if err != nil {
+		return scrapeResult{}, fmt.Errorf("Error getting next feed to fetch: %v", err)
+	}
+
+	slog.DebugContext(ctx, "Found a feed to fetch", feedAttrs(next)...)
+
+	return scrapeFeed(ctx, db, webhooks, next)
+}
+
+// scrapeResult counts the items seen in a fetched feed and how many of them
+// were stored as new posts.
+type scrapeResult struct {
+	Feed       database.Feed
+	Items      int
+	NewPosts   int
+	Bytes      int64
+	StatusCode int
+	// Posts are the posts this scrape inserted.
+	Posts []database.Post
+}
+
+// scrapeFeed fetches feed within scrapeTimeout, stores its new posts and
+// records the attempt in the fetch log. Full content for the new posts is
+// then downloaded within articleTimeout, and the posts queued for webhooks.
+func scrapeFeed(ctx context.Context, db database.Store, webhooks *webhookQueue, feed database.Feed) (scrapeResult, error) {
+	fetchCtx, cancel := context.WithTimeout(ctx, scrapeTimeout)
+	defer cancel()
+
+	started := time.Now()
+	result, err := fetchAndStore(fetchCtx, db, feed)
+	recordFetch(fetchCtx, db, result, started, err)
+
+	if err == nil && result.Feed.FetchFullContent && len(result.Posts) > 0 {
+		articleCtx, cancel := context.WithTimeout(ctx, articleTimeout)
+		defer cancel()
+		fetchFullContent(articleCtx, db, result.Feed, result.Posts)
+	}
+	if len(result.Posts) > 0 {
+		webhooks.enqueue(result.Feed, result.Posts)
+	}
+
+	return result, err
+}
+
+func fetchAndStore(ctx context.Context, db database.Store, feed database.Feed) (scrapeResult, error) {
+	result := scrapeResult{Feed: feed}
+
+	err := db.MarkFeedFetched(ctx, feed.ID)
+	if err != nil {
+		return result, fmt.Errorf("Error marking feed fetched: %v", err)
+	}
+
+	feedData, err := rss.FetchFeed(ctx, feed.Url)
+	if err != nil {
+		var parseErr *rss.ParseError
+		var statusErr *rss.StatusError
+		switch {
+		case errors.As(err, &parseErr):
+			result.StatusCode = parseErr.StatusCode
+			result.Bytes = parseErr.Bytes
+		case errors.As(err, &statusErr):
+			result.StatusCode = statusErr.StatusCode
+		}
+		return result, fmt.Errorf("Error fetching feed: %w", err)
+	}
+
+	result.Items = len(feedData.Channel.Item)
+	result.Bytes = feedData.Bytes
+	result.StatusCode = feedData.StatusCode
+
+	if move, ok := detectFeedMove(feed, feedData); ok {
+		moved, err := followFeedMove(ctx, db, feed, move)
+		if err != nil {
+			slog.Warn("Error following feed move", append(feedAttrs(feed), "new_url", move.url, "reason", move.reason, "error", err)...)
+		} else {
+			feed = moved
+			result.Feed = moved
+		}
+	}
+
+	var created []database.Post
+	for _, item := range feedData.Channel.Item {
+		publishedAt := sql.NullTime{}
+		if t, err := time.Parse(time.RFC1123Z, item.PubDate); err == nil {
+			publishedAt = sql.NullTime{
+				Time:  t,
+				Valid: true,
+			}
+		}
+
+		post, err := db.CreatePost(ctx, database.CreatePostParams{
+			FeedID: feed.ID,
+			Title:  item.Title,
+			Description: sql.NullString{
+				String: htmltext.Sanitize(item.Description),
+				Valid:  true,
+			},
+			Url:         item.Link,
+			PublishedAt: publishedAt,
+			Author: sql.NullString{
+				String: item.AuthorName(),
+				Valid:  item.AuthorName() != "",
+			},
+			// A nil slice would be stored as NULL.
+			Categories: append([]string{}, item.Categories...),
+		})
+
+		if errors.Is(err, sql.ErrNoRows) {
+			// The post is already stored.
+			continue
+		}
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return result, err
+			}
+			return result, fmt.Errorf("Error creating post: %v", err)
+		}
+
+		result.NewPosts++
+		created = append(created, post)
+	}
+
+	result.Posts = created
+
+	return result, nil
+}
+
+// maxArticlesPerScrape bounds how many linked articles one scrape downloads,
+// so a feed that publishes a large batch cannot stall the aggregator.
+const maxArticlesPerScrape = 10
+
+// fetchFullContent downloads the articles new posts link to and stores their
+// extracted main text. Failures are logged and leave the post with only its
+// feed description.
+func fetchFullContent(ctx context.Context, db database.Store, feed database.Feed, posts []database.Post) {
+	if len(posts) > maxArticlesPerScrape {
+		slog.Info("Limiting full content extraction", append(feedAttrs(feed), "posts", len(posts), "limit", maxArticlesPerScrape)...)
+		posts = posts[:maxArticlesPerScrape]
+	}
+
+	for _, post := range posts {
+		if ctx.Err() != nil {
+			return
+		}
+
+		content, err := article.DefaultFetcher.Fetch(ctx, post.Url)
+		if err != nil {
+			slog.Debug("Error extracting article", append(feedAttrs(feed), "post_url", post.Url, "error", err)...)
+			continue
+		}
+
+		err = db.SetPostContent(ctx, database.SetPostContentParams{
+			ID:      post.ID,
+			Content: sql.NullString{String: content, Valid: true},
+		})
+		if err != nil {
+			slog.Warn("Error storing article content", append(feedAttrs(feed), "post_url", post.Url, "error", err)...)
+		}
+	}
+}
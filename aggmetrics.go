@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/eefret/gator/external/rss"
+	"github.com/eefret/gator/internal/database"
+	"github.com/eefret/gator/internal/metrics"
+)
+
+// aggMetrics are the aggregator metrics exposed on /metrics.
+type aggMetrics struct {
+	registry *metrics.Registry
+
+	fetches     *metrics.Counter
+	latency     *metrics.Histogram
+	bytes       *metrics.Counter
+	posts       *metrics.Counter
+	parseErrors *metrics.Counter
+}
+
+func newAggMetrics(db database.Store, interval time.Duration) *aggMetrics {
+	r := metrics.NewRegistry()
+
+	m := &aggMetrics{
+		registry:    r,
+		fetches:     r.NewCounter("gator_fetches_total", "Feed fetches by status.", "status"),
+		latency:     r.NewHistogram("gator_fetch_duration_seconds", "Time spent fetching and storing a feed.", metrics.DefaultBuckets),
+		bytes:       r.NewCounter("gator_downloaded_bytes_total", "Bytes of feed documents downloaded."),
+		posts:       r.NewCounter("gator_posts_total", "Feed items processed. result is inserted for new posts, or existing for items skipped because their post is stored or was pruned; stored posts are never updated.", "result"),
+		parseErrors: r.NewCounter("gator_parse_errors_total", "Feeds that were downloaded but could not be parsed."),
+	}
+
+	r.NewGaugeFunc("gator_queue_lag_seconds", "How long the most overdue feed has been waiting to be fetched.", func() (float64, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return queueLag(ctx, db, interval)
+	})
+
+	return m
+}
+
+func metricsMux(m *aggMetrics) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.registry.Handler())
+	return mux
+}
+
+// observe records the outcome of a single scrape.
+func (m *aggMetrics) observe(result scrapeResult, err error, elapsed time.Duration) {
+	m.latency.Observe(elapsed.Seconds())
+	m.fetches.Inc(fetchStatus(err))
+
+	var parseErr *rss.ParseError
+	if errors.As(err, &parseErr) {
+		m.parseErrors.Inc()
+	}
+
+	m.bytes.Add(float64(result.Bytes))
+	// Posts are insert-only, so the second series counts skipped items rather
+	// than updates. It is kept because inserted over the sum is the share of
+	// each fetch that was new.
+	m.posts.Add(float64(result.NewPosts), "inserted")
+	m.posts.Add(float64(result.Items-result.NewPosts), "existing")
+}
+
+// fetchStatus buckets a scrape error into a low-cardinality label value.
+func fetchStatus(err error) string {
+	var parseErr *rss.ParseError
+	var statusErr *rss.StatusError
+	var tooLargeErr *rss.BodyTooLargeError
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.As(err, &parseErr):
+		return "parse_error"
+	case errors.As(err, &statusErr):
+		return "http_error"
+	case errors.As(err, &tooLargeErr):
+		return "too_large"
+	case errors.Is(err, rss.ErrTooManyRedirects):
+		return "too_many_redirects"
+	}
+	return "error"
+}
+
+// queueLag returns how far past its due time the next feed to fetch is. A
+// feed that was never fetched has been due since it was added.
+func queueLag(ctx context.Context, db database.Store, interval time.Duration) (float64, error) {
+	next, err := db.GetNextFeedToFetch(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if next.FetchIntervalSeconds.Valid {
+		interval = max(interval, time.Duration(next.FetchIntervalSeconds.Int32)*time.Second)
+	}
+
+	due := next.CreatedAt
+	if next.LastFetchedAt.Valid {
+		due = next.LastFetchedAt.Time.Add(interval)
+	}
+
+	lag := time.Since(due).Seconds()
+	if lag < 0 {
+		lag = 0
+	}
+	return lag, nil
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/eefret/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+// parseForce removes --force from args and reports whether it was present.
+func parseForce(args []string) ([]string, bool) {
+	rest := slices.DeleteFunc(slices.Clone(args), func(arg string) bool {
+		return arg == "--force"
+	})
+	return rest, len(rest) != len(args)
+}
+
+// confirm describes what is about to be deleted and asks for the answer to be
+// typed back. Without a terminal to ask on it refuses, so scripts have to
+// pass --force explicitly.
+func confirm(summary, answer string) error {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return fmt.Errorf("This deletes %s. Re-run with --force to confirm", summary)
+	}
+
+	fmt.Fprintf(os.Stderr, "This deletes %s.\nType %q to continue: ", summary, answer)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return fmt.Errorf("Aborted")
+	}
+	if strings.TrimSpace(line) != answer {
+		return fmt.Errorf("Aborted")
+	}
+	return nil
+}
+
+// plural formats n with noun, adding an s unless n is one.
+func plural(n int64, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+func handleReset(s *State, cmd Command, user database.User) error {
+	args, force := parseForce(cmd.Arguments)
+	if len(args) != 0 {
+		return fmt.Errorf("usage: %s [--force]", cmd.Name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if !force {
+		counts, err := s.db.CountAllData(ctx)
+		if err != nil {
+			return fmt.Errorf("Error counting data: %v", err)
+		}
+		summary := fmt.Sprintf("every user, feed, follow and post (%s, %s, %s, %s)",
+			plural(counts.Users, "user"), plural(counts.Feeds, "feed"),
+			plural(counts.Follows, "follow"), plural(counts.Posts, "post"))
+		if err := confirm(summary, "reset"); err != nil {
+			return err
+		}
+	}
+
+	var removed database.CountAllDataRow
+	err := s.db.InTx(ctx, func(q database.Querier) error {
+		var err error
+		removed, err = q.CountAllData(ctx)
+		if err != nil {
+			return fmt.Errorf("Error counting data: %v", err)
+		}
+		if err := q.ResetUsers(ctx); err != nil {
+			return fmt.Errorf("Error resetting users: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.Config.CurrentUserName = ""
+	s.Config.SetUser("")
+
+	fmt.Printf("Deleted %s, %s, %s and %s\n",
+		plural(removed.Users, "user"), plural(removed.Feeds, "feed"),
+		plural(removed.Follows, "follow"), plural(removed.Posts, "post"))
+
+	return nil
+}
+
+func handleDeleteUser(s *State, cmd Command, admin database.User) error {
+	args, force := parseForce(cmd.Arguments)
+	if len(args) != 1 {
+		return fmt.Errorf("usage: %s <name> [--force]", cmd.Name)
+	}
+	name := args[0]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	user, err := s.db.GetUser(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("User %s does not exist", name)
+	}
+	if err != nil {
+		return fmt.Errorf("Error getting user: %v", err)
+	}
+
+	if user.Role == roleAdmin {
+		if err := checkNotLastAdmin(ctx, s); err != nil {
+			return err
+		}
+	}
+
+	if !force {
+		counts, err := s.db.CountUserData(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("Error counting user data: %v", err)
+		}
+		summary := fmt.Sprintf("user %s with %s (%s, also removed for their other followers) and %s",
+			name, plural(counts.Feeds, "added feed"), plural(counts.Posts, "post"),
+			plural(counts.Follows, "follow"))
+		if err := confirm(summary, name); err != nil {
+			return err
+		}
+	}
+
+	var removed database.CountUserDataRow
+	err = s.db.InTx(ctx, func(q database.Querier) error {
+		var err error
+		removed, err = q.CountUserData(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("Error counting user data: %v", err)
+		}
+		deleted, err := q.DeleteUser(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("Error deleting user: %v", err)
+		}
+		if deleted == 0 {
+			return fmt.Errorf("User %s does not exist", name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.Config.CurrentUserName == name {
+		s.Config.CurrentUserName = ""
+		s.Config.SetUser("")
+	}
+
+	fmt.Printf("Deleted user %s with %s, %s and %s\n", name,
+		plural(removed.Feeds, "feed"), plural(removed.Follows, "follow"), plural(removed.Posts, "post"))
+
+	return nil
+}
+
+func handleDeleteFeed(s *State, cmd Command, user database.User, feed database.Feed) error {
+	args, force := parseForce(cmd.Arguments)
+	if len(args) != 1 {
+		return fmt.Errorf("usage: %s <feed_url> [--force]", cmd.Name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if !force {
+		counts, err := s.db.CountFeedData(ctx, feed.ID)
+		if err != nil {
+			return fmt.Errorf("Error counting feed data: %v", err)
+		}
+		summary := fmt.Sprintf("feed %s with %s and %s",
+			feed.Name, plural(counts.Posts, "post"), plural(counts.Follows, "follow"))
+		if err := confirm(summary, feed.Url); err != nil {
+			return err
+		}
+	}
+
+	var removed database.CountFeedDataRow
+	err := s.db.InTx(ctx, func(q database.Querier) error {
+		var err error
+		removed, err = q.CountFeedData(ctx, feed.ID)
+		if err != nil {
+			return fmt.Errorf("Error counting feed data: %v", err)
+		}
+		if err := q.DeleteFeed(ctx, feed.ID); err != nil {
+			return fmt.Errorf("Error deleting feed: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Deleted feed %s with %s and %s\n",
+		feed.Name, plural(removed.Posts, "post"), plural(removed.Follows, "follow"))
+
+	return nil
+}
+
+func handlePurgePosts(s *State, cmd Command, user database.User) error {
+	args, force := parseForce(cmd.Arguments)
+
+	var age time.Duration
+	var feedRef string
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "--older-than", "--feed":
+			if i+1 >= len(args) {
+				return fmt.Errorf("%s requires a value", arg)
+			}
+			i++
+			if arg == "--feed" {
+				feedRef = args[i]
+				continue
+			}
+			d, err := time.ParseDuration(args[i])
+			if err != nil || d <= 0 {
+				return fmt.Errorf("invalid duration: %s", args[i])
+			}
+			age = d
+		default:
+			return fmt.Errorf("usage: %s --older-than <duration> [--feed <feed_url>] [--force]", cmd.Name)
+		}
+	}
+	if age == 0 {
+		return fmt.Errorf("usage: %s --older-than <duration> [--feed <feed_url>] [--force]", cmd.Name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	params := database.GetPostIDsOlderThanParams{Cutoff: time.Now().Add(-age)}
+	scope := "every feed"
+	if feedRef != "" {
+		feed, err := resolveFeedExact(ctx, s, feedRef)
+		if err != nil {
+			return err
+		}
+		params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+		scope = feed.Name
+	}
+
+	if !force {
+		summary := fmt.Sprintf("posts published before %s from %s, except saved ones",
+			params.Cutoff.Format(time.RFC3339), scope)
+		if err := confirm(summary, "purge"); err != nil {
+			return err
+		}
+	}
+
+	var deleted int64
+	err := s.db.InTx(ctx, func(q database.Querier) error {
+		ids, err := q.GetPostIDsOlderThan(ctx, params)
+		if err != nil {
+			return fmt.Errorf("Error getting posts: %v", err)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		// Remember the URLs so the next fetch doesn't store them again.
+		if err := q.CreatePrunedPosts(ctx, ids); err != nil {
+			return fmt.Errorf("Error recording purged posts: %v", err)
+		}
+		deleted, err = q.DeletePostsByID(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("Error purging posts: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Deleted %s older than %s from %s\n", plural(deleted, "post"), age, scope)
+
+	return nil
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/mail"
+	"slices"
+	"time"
+	"unicode/utf8"
+
+	"github.com/eefret/gator/internal/database"
+	"github.com/eefret/gator/internal/digest"
+	"github.com/eefret/gator/internal/htmltext"
+	"github.com/google/uuid"
+)
+
+const (
+	// digestFirstWindow is how far back a user's first digest reaches.
+	digestFirstWindow = 24 * time.Hour
+	// digestMaxPosts caps the posts in one email.
+	digestMaxPosts = 200
+	// digestPageSize is how many posts are read at a time.
+	digestPageSize = 100
+	// digestSummaryLength is the longest post summary, in characters.
+	digestSummaryLength = 300
+)
+
+// handleDigest dispatches the digest commands:
+//
+//	digest [--dry-run] [--user <name>]
+//	digest email <address>|off
+func handleDigest(s *State, cmd Command) error {
+	if len(cmd.Arguments) > 0 && cmd.Arguments[0] == "email" {
+		return middlewareLoggedIn(handleDigestEmail)(s, Command{Name: "digest email", Arguments: cmd.Arguments[1:]})
+	}
+	return middlewareAdmin(handleDigestSend)(s, cmd)
+}
+
+// handleDigestSend emails every user with an address their unread posts since
+// their previous digest. Only admins may run it, since it mails every user.
+func handleDigestSend(s *State, cmd Command, admin database.User) error {
+
+	dryRun := false
+	onlyUser := ""
+	for i := 0; i < len(cmd.Arguments); i++ {
+		switch cmd.Arguments[i] {
+		case "--dry-run":
+			dryRun = true
+		case "--user":
+			if i+1 >= len(cmd.Arguments) {
+				return fmt.Errorf("--user requires a value")
+			}
+			i++
+			onlyUser = cmd.Arguments[i]
+		default:
+			return fmt.Errorf("usage: %s [--dry-run] [--user <name>] | %s email <address>|off", cmd.Name, cmd.Name)
+		}
+	}
+
+	if !dryRun && (s.Config.SMTPHost == "" || s.Config.SMTPFrom == "") {
+		return fmt.Errorf("smtp_host and smtp_from must be set in the config to send digests")
+	}
+
+	templates, err := digest.LoadTemplates(s.Config.DigestTextTemplate, s.Config.DigestHTMLTemplate)
+	if err != nil {
+		return fmt.Errorf("Error loading digest templates: %v", err)
+	}
+	mailer := digest.Mailer{
+		Host:     s.Config.SMTPHost,
+		Port:     s.Config.SMTPPort,
+		Username: s.Config.SMTPUsername,
+		Password: s.Config.SMTPPassword,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	users, err := s.db.GetUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("Error getting users: %v", err)
+	}
+
+	failed := 0
+	for _, user := range users {
+		if onlyUser != "" && user.Name != onlyUser {
+			continue
+		}
+		if !user.Email.Valid {
+			if onlyUser != "" {
+				return fmt.Errorf("%s has no email address; set one with 'digest email <address>'", user.Name)
+			}
+			continue
+		}
+
+		if err := sendDigest(ctx, s, templates, mailer, user, dryRun); err != nil {
+			fmt.Printf("%s: %v\n", user.Name, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d digests failed", failed)
+	}
+	return nil
+}
+
+// sendDigest builds and sends one user's digest, then moves their
+// last_digest_at forward so the next digest starts where this one ended. A
+// digest holds at most digestMaxPosts posts, oldest first; the rest wait for
+// the next one.
+func sendDigest(ctx context.Context, s *State, templates *digest.Templates, mailer digest.Mailer, user database.User, dryRun bool) error {
+	until := time.Now().UTC()
+	since := until.Add(-digestFirstWindow)
+	if user.LastDigestAt.Valid {
+		since = user.LastDigestAt.Time
+	}
+
+	rules, tags, err := loadFilters(ctx, s, user)
+	if err != nil {
+		return err
+	}
+
+	// Hidden posts don't count towards the cap. Once it is reached, posts
+	// created at the same instant as the last one are still included, since
+	// last_digest_at can't tell them apart.
+	type entry struct {
+		feed string
+		post digest.Post
+	}
+	var entries []entry
+	reached := until
+	params := database.GetDigestPostsForUserParams{
+		UserID:   user.ID,
+		Since:    since,
+		Until:    until,
+		PageSize: digestPageSize,
+	}
+pages:
+	for {
+		page, err := s.db.GetDigestPostsForUser(ctx, params)
+		if err != nil {
+			return fmt.Errorf("Error getting posts: %v", err)
+		}
+
+		for _, row := range page {
+			if len(entries) >= digestMaxPosts && !row.CreatedAt.Equal(reached) {
+				break pages
+			}
+			params.Since = row.CreatedAt
+			params.AfterID = uuid.NullUUID{UUID: row.ID, Valid: true}
+
+			post := database.GetPostsForUserRow(row)
+			result := rules.Evaluate(filterPost(post), tags[post.FeedID])
+			if result.Hide {
+				continue
+			}
+			entries = append(entries, entry{feed: post.FeedName, post: digest.Post{
+				Title:       post.Title,
+				URL:         post.Url,
+				Summary:     truncate(htmltext.Render(post.Description.String, 1<<20), digestSummaryLength),
+				Author:      post.Author.String,
+				PublishedAt: post.PublishedAt.Time,
+				Highlighted: result.Highlight,
+			}})
+			if len(entries) == digestMaxPosts {
+				reached = row.CreatedAt
+			}
+		}
+
+		if len(page) < digestPageSize {
+			break
+		}
+	}
+
+	// Group the posts by feed, newest first within each.
+	slices.SortStableFunc(entries, func(a, b entry) int {
+		if c := cmp.Compare(a.feed, b.feed); c != 0 {
+			return c
+		}
+		return b.post.PublishedAt.Compare(a.post.PublishedAt)
+	})
+
+	d := digest.Digest{User: user.Name, Since: since, Until: until}
+	for _, e := range entries {
+		if len(d.Feeds) == 0 || d.Feeds[len(d.Feeds)-1].Name != e.feed {
+			d.Feeds = append(d.Feeds, digest.Feed{Name: e.feed})
+		}
+		feed := &d.Feeds[len(d.Feeds)-1]
+		feed.Posts = append(feed.Posts, e.post)
+		d.Total++
+	}
+
+	if d.Total == 0 {
+		fmt.Printf("%s: no new posts\n", user.Name)
+		return nil
+	}
+
+	msg, err := templates.Render(d, s.Config.SMTPFrom, user.Email.String)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Printf("To: %s\nSubject: %s\n\n%s\n", msg.To, msg.Subject, msg.Text)
+		return nil
+	}
+
+	if err := mailer.Send(msg); err != nil {
+		return fmt.Errorf("Error sending digest: %v", err)
+	}
+
+	err = s.db.SetUserLastDigestAt(ctx, database.SetUserLastDigestAtParams{
+		ID:           user.ID,
+		LastDigestAt: sql.NullTime{Time: reached, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("Error recording digest: %v", err)
+	}
+
+	fmt.Printf("%s: sent %d posts to %s\n", user.Name, d.Total, user.Email.String)
+	return nil
+}
+
+// handleDigestEmail sets or clears the current user's digest address.
+func handleDigestEmail(s *State, cmd Command, user database.User) error {
+	if len(cmd.Arguments) != 1 {
+		return fmt.Errorf("usage: %s <address>|off", cmd.Name)
+	}
+
+	email := sql.NullString{}
+	if cmd.Arguments[0] != "off" {
+		addr, err := mail.ParseAddress(cmd.Arguments[0])
+		if err != nil {
+			return fmt.Errorf("Invalid email address %q", cmd.Arguments[0])
+		}
+		email = sql.NullString{String: addr.Address, Valid: true}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := s.db.SetUserEmail(ctx, database.SetUserEmailParams{ID: user.ID, Email: email})
+	if err != nil {
+		return fmt.Errorf("Error setting email: %v", err)
+	}
+
+	if email.Valid {
+		fmt.Printf("Digests for %s will be sent to %s\n", user.Name, email.String)
+	} else {
+		fmt.Printf("Digests for %s are off\n", user.Name)
+	}
+	return nil
+}
+
+// truncate shortens text to at most n characters, marking the cut.
+func truncate(text string, n int) string {
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,247 @@
+package rss
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
+
+const (
+	// DefaultTimeout bounds a whole request, including reading the body.
+	DefaultTimeout = 10 * time.Second
+	// DefaultMaxBodySize is the largest decompressed document accepted.
+	DefaultMaxBodySize = 10 << 20
+	// DefaultMaxRedirects is how many redirects are followed per request.
+	DefaultMaxRedirects = 5
+	// DefaultUserAgent identifies gator to feed servers.
+	DefaultUserAgent = "gator"
+)
+
+// ErrTooManyRedirects is returned when a feed redirects more than the
+// client's MaxRedirects.
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+// StatusError reports a response with a non-2xx status code.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected HTTP status %s from %s", e.Status, e.URL)
+}
+
+// BodyTooLargeError reports a document larger than the client's MaxBodySize.
+type BodyTooLargeError struct {
+	URL   string
+	Limit int64
+}
+
+func (e *BodyTooLargeError) Error() string {
+	return fmt.Sprintf("feed %s is larger than %d bytes", e.URL, e.Limit)
+}
+
+// ClientOptions configure a Client. Zero values select the defaults above.
+type ClientOptions struct {
+	Timeout      time.Duration
+	MaxBodySize  int64
+	MaxRedirects int
+	UserAgent    string
+}
+
+// Client downloads feeds over a shared, connection-reusing HTTP client.
+type Client struct {
+	http        *http.Client
+	maxBodySize int64
+	userAgent   string
+}
+
+// DefaultClient is used by FetchFeed.
+var DefaultClient = NewClient(ClientOptions{})
+
+// NewClient returns a Client configured by opts.
+func NewClient(opts ClientOptions) *Client {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultMaxBodySize
+	}
+	if opts.MaxRedirects <= 0 {
+		opts.MaxRedirects = DefaultMaxRedirects
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = DefaultUserAgent
+	}
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   4,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: opts.Timeout,
+		// Compression is negotiated and decoded by the client so brotli is
+		// supported alongside gzip and deflate.
+		DisableCompression: true,
+	}
+
+	maxRedirects := opts.MaxRedirects
+	return &Client{
+		http: &http.Client{
+			Transport: transport,
+			Timeout:   opts.Timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) > maxRedirects {
+					return fmt.Errorf("%w: stopped after %d", ErrTooManyRedirects, maxRedirects)
+				}
+				return nil
+			},
+		},
+		maxBodySize: opts.MaxBodySize,
+		userAgent:   opts.UserAgent,
+	}
+}
+
+// FetchFeed downloads and parses the feed at feedURL using DefaultClient.
+func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
+	return DefaultClient.FetchFeed(ctx, feedURL)
+}
+
+// FetchFeed downloads and parses the feed at feedURL. Non-2xx responses are
+// reported as *StatusError, oversized documents as *BodyTooLargeError and
+// undecodable ones as *ParseError.
+func (c *Client) FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
+	started := time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, text/xml;q=0.9, */*;q=0.8")
+	req.Header.Set("Accept-Encoding", "gzip, br, deflate")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// Drain a little of the body so the connection can be reused.
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		return nil, &StatusError{URL: feedURL, StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	body, err := decodeBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, c.maxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > c.maxBodySize {
+		return nil, &BodyTooLargeError{URL: feedURL, Limit: c.maxBodySize}
+	}
+
+	slog.DebugContext(ctx, "Downloaded feed",
+		"url", feedURL,
+		"http_status", resp.StatusCode,
+		"content_encoding", resp.Header.Get("Content-Encoding"),
+		"bytes", len(data),
+		"duration", time.Since(started).Round(time.Millisecond).String(),
+	)
+
+	feed, err := parseFeed(data, contentTypeCharset(resp.Header.Get("Content-Type")))
+	if err != nil {
+		slog.DebugContext(ctx, "Error parsing feed", "url", feedURL, "error", err)
+		return nil, &ParseError{StatusCode: resp.StatusCode, Bytes: int64(len(data)), Err: err}
+	}
+	feed.Bytes = int64(len(data))
+	feed.StatusCode = resp.StatusCode
+	if feed.Partial {
+		slog.WarnContext(ctx, "Recovered partial feed", "url", feedURL, "items", len(feed.Channel.Item))
+	}
+	feed.PermanentURL = permanentRedirect(resp)
+
+	return feed, nil
+}
+
+// permanentRedirect returns the final URL of resp when it was reached only
+// through permanent redirects.
+func permanentRedirect(resp *http.Response) string {
+	req := resp.Request
+	if req == nil || req.Response == nil {
+		return ""
+	}
+
+	for r := req; r.Response != nil; r = r.Response.Request {
+		switch r.Response.StatusCode {
+		case http.StatusMovedPermanently, http.StatusPermanentRedirect:
+		default:
+			return ""
+		}
+	}
+
+	return req.URL.String()
+}
+
+// deflateReader decodes an HTTP deflate body. The encoding is zlib-wrapped
+// (RFC 9110), but some servers send raw deflate data, so a body without a
+// zlib header is read as raw deflate.
+func deflateReader(body io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(body)
+	header, err := br.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		r, err := zlib.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("decoding deflate body: %w", err)
+		}
+		return r, nil
+	}
+	return flate.NewReader(br), nil
+}
+
+// decodeBody wraps the response body in a decompressor for its
+// Content-Encoding.
+func decodeBody(resp *http.Response) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+		return io.NopCloser(resp.Body), nil
+	case "gzip", "x-gzip":
+		r, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("decoding gzip body: %w", err)
+		}
+		return r, nil
+	case "br":
+		return io.NopCloser(brotli.NewReader(resp.Body)), nil
+	case "deflate":
+		return deflateReader(resp.Body)
+	default:
+		return nil, fmt.Errorf("unsupported Content-Encoding %q", resp.Header.Get("Content-Encoding"))
+	}
+}
@@ -0,0 +1,243 @@
+package rss_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/eefret/gator/external/rss"
+)
+
+const sampleFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+<channel>
+  <title>Example &amp; Co</title>
+  <link>https://example.com/</link>
+  <description>Example feed</description>
+  <item>
+    <title>First post</title>
+    <link>https://example.com/first</link>
+    <description>&lt;p&gt;Hello&lt;/p&gt;</description>
+    <pubDate>Mon, 02 Jan 2006 15:04:05 -0700</pubDate>
+  </item>
+</channel>
+</rss>`
+
+func gzipped(t *testing.T, s string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(s)); err != nil {
+		t.Fatalf("Failed to gzip: %v", err)
+	}
+	w.Close()
+	return buf.Bytes()
+}
+
+func deflated(t *testing.T, s string, raw bool) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser = zlib.NewWriter(&buf)
+	if raw {
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	}
+	if _, err := w.Write([]byte(s)); err != nil {
+		t.Fatalf("Failed to deflate: %v", err)
+	}
+	w.Close()
+	return buf.Bytes()
+}
+
+func brotlied(t *testing.T, s string) []byte {
+	var buf bytes.Buffer
+	w := brotli.NewWriter(&buf)
+	if _, err := w.Write([]byte(s)); err != nil {
+		t.Fatalf("Failed to brotli: %v", err)
+	}
+	w.Close()
+	return buf.Bytes()
+}
+
+// TestFetchFeedEncodings checks that plain, gzip, deflate (zlib-wrapped, or
+// raw as some servers send it) and brotli responses all decode to the same
+// feed.
+func TestFetchFeedEncodings(t *testing.T) {
+	tests := []struct {
+		encoding string
+		body     []byte
+	}{
+		{"", []byte(sampleFeed)},
+		{"gzip", gzipped(t, sampleFeed)},
+		{"deflate", deflated(t, sampleFeed, false)},
+		{"deflate", deflated(t, sampleFeed, true)},
+		{"br", brotlied(t, sampleFeed)},
+	}
+
+	for _, tt := range tests {
+		encoding, body := tt.encoding, tt.body
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.Contains(r.Header.Get("Accept-Encoding"), "br") {
+				t.Errorf("Expected Accept-Encoding to advertise br, got %q", r.Header.Get("Accept-Encoding"))
+			}
+			if encoding != "" {
+				w.Header().Set("Content-Encoding", encoding)
+			}
+			w.Write(body)
+		}))
+
+		feed, err := rss.NewClient(rss.ClientOptions{}).FetchFeed(context.Background(), srv.URL)
+		srv.Close()
+		if err != nil {
+			t.Fatalf("Encoding %q: expected FetchFeed to succeed, got error: %v", encoding, err)
+		}
+
+		if feed.Channel.Title != "Example & Co" {
+			t.Errorf("Encoding %q: unexpected title %q", encoding, feed.Channel.Title)
+		}
+		if len(feed.Channel.Item) != 1 || feed.Channel.Item[0].Description != "<p>Hello</p>" {
+			t.Errorf("Encoding %q: unexpected items %+v", encoding, feed.Channel.Item)
+		}
+		if feed.StatusCode != http.StatusOK || feed.Bytes != int64(len(sampleFeed)) {
+			t.Errorf("Encoding %q: unexpected status %d or size %d", encoding, feed.StatusCode, feed.Bytes)
+		}
+	}
+}
+
+// TestFetchFeedStatusError checks that a 404 page is reported as a
+// StatusError instead of an XML parse error.
+func TestFetchFeedStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("<html><body>Not found</body></html>"))
+	}))
+	defer srv.Close()
+
+	_, err := rss.NewClient(rss.ClientOptions{}).FetchFeed(context.Background(), srv.URL)
+
+	var statusErr *rss.StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("Expected StatusError, got %v", err)
+	}
+	if statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", statusErr.StatusCode)
+	}
+}
+
+// TestFetchFeedBodyTooLarge checks that documents above MaxBodySize are
+// rejected, measured after decompression.
+func TestFetchFeedBodyTooLarge(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(gzipped(t, sampleFeed))
+	}))
+	defer srv.Close()
+
+	client := rss.NewClient(rss.ClientOptions{MaxBodySize: 100})
+	_, err := client.FetchFeed(context.Background(), srv.URL)
+
+	var tooLarge *rss.BodyTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("Expected BodyTooLargeError, got %v", err)
+	}
+}
+
+// TestFetchFeedRedirectLimit checks that redirect loops stop at
+// MaxRedirects.
+func TestFetchFeedRedirectLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	client := rss.NewClient(rss.ClientOptions{MaxRedirects: 2})
+	_, err := client.FetchFeed(context.Background(), srv.URL)
+	if !errors.Is(err, rss.ErrTooManyRedirects) {
+		t.Fatalf("Expected ErrTooManyRedirects, got %v", err)
+	}
+}
+
+// TestFetchFeedParseError checks that malformed XML is reported as a
+// ParseError carrying the response details.
+func TestFetchFeedParseError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<rss><channel><title>broken"))
+	}))
+	defer srv.Close()
+
+	_, err := rss.NewClient(rss.ClientOptions{}).FetchFeed(context.Background(), srv.URL)
+
+	var parseErr *rss.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected ParseError, got %v", err)
+	}
+	if parseErr.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 on ParseError, got %d", parseErr.StatusCode)
+	}
+}
+
+// TestFetchFeedPermanentRedirect checks that PermanentURL is only set when
+// every hop was a permanent redirect.
+func TestFetchFeedPermanentRedirect(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/moved", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusPermanentRedirect)
+	})
+	mux.HandleFunc("/temporary", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusFound)
+	})
+	mux.HandleFunc("/mixed", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/temporary", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(sampleFeed))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	cases := map[string]string{
+		"/old":       srv.URL + "/new",
+		"/temporary": "",
+		"/mixed":     "",
+		"/new":       "",
+	}
+
+	client := rss.NewClient(rss.ClientOptions{})
+	for path, want := range cases {
+		feed, err := client.FetchFeed(context.Background(), srv.URL+path)
+		if err != nil {
+			t.Fatalf("%s: expected FetchFeed to succeed, got error: %v", path, err)
+		}
+		if feed.PermanentURL != want {
+			t.Errorf("%s: expected PermanentURL %q, got %q", path, want, feed.PermanentURL)
+		}
+	}
+}
+
+// TestFetchFeedContentTypeCharset checks that the Content-Type charset is
+// honored over a missing or conflicting XML declaration.
+func TestFetchFeedContentTypeCharset(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml; charset=ISO-8859-1")
+		w.Write([]byte("<?xml version=\"1.0\" encoding=\"UTF-8\"?><rss><channel><title>Caf\xe9</title></channel></rss>"))
+	}))
+	defer srv.Close()
+
+	feed, err := rss.NewClient(rss.ClientOptions{}).FetchFeed(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("Expected FetchFeed to succeed, got error: %v", err)
+	}
+	if feed.Channel.Title != "Café" {
+		t.Errorf("Expected title %q, got %q", "Café", feed.Channel.Title)
+	}
+}
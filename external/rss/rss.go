@@ -1,58 +1,126 @@
 package rss
 
 import (
-	"context"
+	"bytes"
 	"encoding/xml"
+	"fmt"
 	"html"
 	"io"
-	"net/http"
-	"time"
+	"mime"
+	"strings"
+
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Namespaces of the channel extensions gator understands.
+const (
+	atomNS   = "http://www.w3.org/2005/Atom"
+	itunesNS = "http://www.itunes.com/dtds/podcast-1.0.dtd"
 )
 
 type RSSFeed struct {
 	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
-		Description string    `xml:"description"`
-		Item        []RSSItem `xml:"item"`
+		Title string `xml:"title"`
+		// AtomLinks must precede Link: an unqualified tag also matches
+		// namespaced elements, and the first matching field wins.
+		AtomLinks   []AtomLink `xml:"http://www.w3.org/2005/Atom link"`
+		Link        string     `xml:"link"`
+		Description string     `xml:"description"`
+		// NewFeedURL is the iTunes podcast convention for announcing a move.
+		NewFeedURL string    `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd new-feed-url"`
+		Item       []RSSItem `xml:"item"`
 	} `xml:"channel"`
+
+	// Bytes is the size of the downloaded document.
+	Bytes int64 `xml:"-"`
+	// StatusCode is the HTTP status of the response.
+	StatusCode int `xml:"-"`
+	// Partial is set when the document was cut off or broken and only the
+	// items before the damage were recovered.
+	Partial bool `xml:"-"`
+	// PermanentURL is where the feed was fetched from when every redirect
+	// on the way was permanent (301 or 308). Empty if there was none.
+	PermanentURL string `xml:"-"`
 }
 
-type RSSItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+// AtomLink is an <atom:link> element in an RSS channel.
+type AtomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
 }
 
-func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
-	
-	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
-	if err != nil {
-		return nil, err
+// SelfURL returns the feed's declared canonical address, if any.
+func (f *RSSFeed) SelfURL() string {
+	for _, link := range f.Channel.AtomLinks {
+		if link.Rel == "self" {
+			return strings.TrimSpace(link.Href)
+		}
 	}
+	return ""
+}
 
-	req.Header.Set("User-Agent", "gator")
+type RSSItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Author      string   `xml:"author"`
+	Categories  []string `xml:"category"`
+	Description string   `xml:"description"`
+	PubDate     string   `xml:"pubDate"`
+}
 
-	client := &http.Client{
-		Timeout: 5 * time.Second,
+// AuthorName returns the item's author, preferring the Dublin Core creator,
+// which is a plain name, over RSS's author, which is usually an email address.
+func (i RSSItem) AuthorName() string {
+	if creator := strings.TrimSpace(i.Creator); creator != "" {
+		return creator
 	}
+	return strings.TrimSpace(i.Author)
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
+// ParseError reports a feed that was downloaded but could not be decoded.
+type ParseError struct {
+	StatusCode int
+	Bytes      int64
+	Err        error
+}
 
-	defer resp.Body.Close()
+func (e *ParseError) Error() string {
+	return "parsing feed: " + e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ParseFeed decodes an RSS document and unescapes HTML entities in its text.
+// Documents in encodings other than UTF-8 are transcoded according to their
+// XML declaration. Documents the strict decoder rejects are parsed again in
+// tolerant mode; see parseLenient.
+func ParseFeed(data []byte) (*RSSFeed, error) {
+	return parseFeed(data, "")
+}
+
+// parseFeed decodes data, treating httpCharset, the charset parameter of the
+// response's Content-Type, as authoritative over the XML declaration as
+// RFC 7303 requires.
+func parseFeed(data []byte, httpCharset string) (*RSSFeed, error) {
+	data = trimPreamble(data)
 
-	data, err := io.ReadAll(resp.Body)
+	decoder, err := newDecoder(data, httpCharset)
 	if err != nil {
 		return nil, err
 	}
 
 	var feed RSSFeed
-	if err := xml.Unmarshal(data, &feed); err != nil {
-		return nil, err
+	if err := decoder.Decode(&feed); err != nil {
+		lenient, lenientErr := parseLenient(data, httpCharset)
+		if lenientErr != nil {
+			// Report the strict error; it points at the first real problem.
+			return nil, err
+		}
+		feed = *lenient
 	}
 
 	// Unescape HTML entities in the feed
@@ -62,9 +130,161 @@ func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 	for f, v := range feed.Channel.Item {
 		feed.Channel.Item[f].Title = html.UnescapeString(v.Title)
 		feed.Channel.Item[f].Description = html.UnescapeString(v.Description)
+		feed.Channel.Item[f].Creator = html.UnescapeString(v.Creator)
+		feed.Channel.Item[f].Author = html.UnescapeString(v.Author)
+		for c, category := range v.Categories {
+			feed.Channel.Item[f].Categories[c] = strings.TrimSpace(html.UnescapeString(category))
+		}
+	}
+
+	return &feed, nil
+}
+
+// trimPreamble strips a UTF-8 byte order mark and whitespace in front of the
+// XML declaration, both of which the decoder rejects.
+func trimPreamble(data []byte) []byte {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	return bytes.TrimLeft(data, " \t\r\n")
+}
+
+func newDecoder(data []byte, httpCharset string) (*xml.Decoder, error) {
+	var r io.Reader = bytes.NewReader(data)
+
+	if httpCharset != "" {
+		enc, err := htmlindex.Get(httpCharset)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported charset %q", httpCharset)
+		}
+		if enc != unicode.UTF8 {
+			r = enc.NewDecoder().Reader(r)
+		}
 	}
 
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		if httpCharset != "" {
+			// Already transcoded to UTF-8 above.
+			return input, nil
+		}
+		return charsetReader(label, input)
+	}
+
+	return decoder, nil
+}
+
+// parseLenient decodes real-world feeds the strict decoder rejects: bare
+// ampersands, HTML entities such as &nbsp; and unclosed HTML tags are
+// accepted, and when the document breaks off the items decoded so far are
+// kept and the feed is marked Partial.
+func parseLenient(data []byte, httpCharset string) (*RSSFeed, error) {
+	decoder, err := newDecoder(data, httpCharset)
+	if err != nil {
+		return nil, err
+	}
+	decoder.Strict = false
+	decoder.AutoClose = htmlVoidElements
+	decoder.Entity = xml.HTMLEntity
 
+	var feed RSSFeed
+	inChannel := false
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return partialFeed(&feed, err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "channel" {
+				inChannel = true
+				continue
+			}
+			if !inChannel {
+				continue
+			}
+			if err := decodeChannelElement(decoder, &feed, t); err != nil {
+				return partialFeed(&feed, err)
+			}
+		case xml.EndElement:
+			if t.Name.Local == "channel" {
+				inChannel = false
+			}
+		}
+	}
+
+	if !inChannel && len(feed.Channel.Item) == 0 && feed.Channel.Title == "" {
+		return nil, fmt.Errorf("no RSS channel found")
+	}
 
 	return &feed, nil
-}
\ No newline at end of file
+}
+
+// htmlVoidElements are the HTML elements written without a closing tag that
+// show up unescaped in descriptions. It is xml.HTMLAutoClose without link and
+// source, which are RSS elements with content.
+var htmlVoidElements = []string{
+	"area", "base", "br", "col", "embed", "hr", "img", "input",
+	"keygen", "meta", "param", "track", "wbr",
+}
+
+// decodeChannelElement decodes a direct child of <channel> into feed.
+func decodeChannelElement(decoder *xml.Decoder, feed *RSSFeed, start xml.StartElement) error {
+	switch {
+	case start.Name.Local == "item":
+		var item RSSItem
+		if err := decoder.DecodeElement(&item, &start); err != nil {
+			return err
+		}
+		feed.Channel.Item = append(feed.Channel.Item, item)
+		return nil
+	case start.Name.Space == atomNS && start.Name.Local == "link":
+		var link AtomLink
+		if err := decoder.DecodeElement(&link, &start); err != nil {
+			return err
+		}
+		feed.Channel.AtomLinks = append(feed.Channel.AtomLinks, link)
+		return nil
+	case start.Name.Space == itunesNS && start.Name.Local == "new-feed-url":
+		return decoder.DecodeElement(&feed.Channel.NewFeedURL, &start)
+	case start.Name.Local == "title":
+		return decoder.DecodeElement(&feed.Channel.Title, &start)
+	case start.Name.Local == "link":
+		return decoder.DecodeElement(&feed.Channel.Link, &start)
+	case start.Name.Local == "description":
+		return decoder.DecodeElement(&feed.Channel.Description, &start)
+	}
+	return decoder.Skip()
+}
+
+// partialFeed returns what was recovered before err, as long as at least one
+// item survived.
+func partialFeed(feed *RSSFeed, err error) (*RSSFeed, error) {
+	if len(feed.Channel.Item) == 0 {
+		return nil, err
+	}
+	feed.Partial = true
+	return feed, nil
+}
+
+// charsetReader transcodes input from the encoding named in an XML
+// declaration to UTF-8. Labels are resolved as browsers do, so ISO-8859-1 is
+// read as its Windows-1252 superset.
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	enc, err := htmlindex.Get(label)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported charset %q", label)
+	}
+	return enc.NewDecoder().Reader(input), nil
+}
+
+// contentTypeCharset returns the charset parameter of a Content-Type header.
+func contentTypeCharset(contentType string) string {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(params["charset"])
+}
@@ -0,0 +1,179 @@
+package rss_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eefret/gator/external/rss"
+)
+
+// TestParseFeedMoveHints checks that atom:link and itunes:new-feed-url are
+// decoded without clobbering the channel's plain <link>.
+func TestParseFeedMoveHints(t *testing.T) {
+	data := []byte(`<?xml version="1.0"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd">
+<channel>
+  <title>Podcast</title>
+  <link>https://example.com/</link>
+  <atom:link rel="hub" href="https://hub.example.com/"/>
+  <atom:link rel="self" href=" https://feeds.example.com/podcast.xml " type="application/rss+xml"/>
+  <itunes:new-feed-url>https://new.example.com/podcast.xml</itunes:new-feed-url>
+</channel>
+</rss>`)
+
+	feed, err := rss.ParseFeed(data)
+	if err != nil {
+		t.Fatalf("Expected ParseFeed to succeed, got error: %v", err)
+	}
+
+	if feed.Channel.Link != "https://example.com/" {
+		t.Errorf("Expected channel link to be preserved, got %q", feed.Channel.Link)
+	}
+	if got := feed.SelfURL(); got != "https://feeds.example.com/podcast.xml" {
+		t.Errorf("Unexpected self URL %q", got)
+	}
+	if feed.Channel.NewFeedURL != "https://new.example.com/podcast.xml" {
+		t.Errorf("Unexpected new feed URL %q", feed.Channel.NewFeedURL)
+	}
+}
+
+// TestParseFeedAuthorCategories checks item authors and categories.
+func TestParseFeedAuthorCategories(t *testing.T) {
+	data := []byte(`<?xml version="1.0"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<channel>
+  <title>Blog</title>
+  <item>
+    <title>With creator</title>
+    <author>jane@example.com (Jane)</author>
+    <dc:creator>Jane Doe</dc:creator>
+    <category>Go</category>
+    <category> Security &amp; Privacy </category>
+  </item>
+  <item>
+    <title>Author only</title>
+    <author>john@example.com</author>
+  </item>
+</channel>
+</rss>`)
+
+	feed, err := rss.ParseFeed(data)
+	if err != nil {
+		t.Fatalf("Expected ParseFeed to succeed, got error: %v", err)
+	}
+
+	items := feed.Channel.Item
+	if got := items[0].AuthorName(); got != "Jane Doe" {
+		t.Errorf("Expected dc:creator to win, got %q", got)
+	}
+	if got := items[1].AuthorName(); got != "john@example.com" {
+		t.Errorf("Expected author fallback, got %q", got)
+	}
+	if len(items[0].Categories) != 2 || items[0].Categories[1] != "Security & Privacy" {
+		t.Errorf("Unexpected categories %q", items[0].Categories)
+	}
+}
+
+// TestParseFeedDeclaredCharset checks that documents declaring a legacy
+// encoding in their XML declaration are transcoded to UTF-8.
+func TestParseFeedDeclaredCharset(t *testing.T) {
+	cases := map[string]struct {
+		data []byte
+		want string
+	}{
+		"ISO-8859-1": {
+			// "Café Olé" with é encoded as 0xE9.
+			data: []byte("<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><rss><channel><title>Caf\xe9 Ol\xe9</title></channel></rss>"),
+			want: "Café Olé",
+		},
+		"windows-1252": {
+			// Curly quotes live in the 0x80-0x9F range.
+			data: []byte("<?xml version=\"1.0\" encoding=\"windows-1252\"?><rss><channel><title>\x93quoted\x94</title></channel></rss>"),
+			want: "“quoted”",
+		},
+		"Shift_JIS": {
+			// "日本" in Shift_JIS.
+			data: []byte("<?xml version=\"1.0\" encoding=\"Shift_JIS\"?><rss><channel><title>\x93\xfa\x96{</title></channel></rss>"),
+			want: "日本",
+		},
+	}
+
+	for name, tc := range cases {
+		feed, err := rss.ParseFeed(tc.data)
+		if err != nil {
+			t.Fatalf("%s: expected ParseFeed to succeed, got error: %v", name, err)
+		}
+		if feed.Channel.Title != tc.want {
+			t.Errorf("%s: expected title %q, got %q", name, tc.want, feed.Channel.Title)
+		}
+	}
+}
+
+// TestParseFeedUnknownCharset checks that an unknown encoding is an error.
+func TestParseFeedUnknownCharset(t *testing.T) {
+	data := []byte(`<?xml version="1.0" encoding="x-made-up"?><rss><channel><title>t</title></channel></rss>`)
+	if _, err := rss.ParseFeed(data); err == nil {
+		t.Fatal("Expected error for unknown charset, got nil")
+	}
+}
+
+// TestParseFeedBrokenCorpus runs the fixtures in testdata/broken, each a
+// malformed feed seen in the wild, through the tolerant parser.
+func TestParseFeedBrokenCorpus(t *testing.T) {
+	cases := []struct {
+		file      string
+		title     string
+		items     int
+		firstItem string
+		firstLink string
+		partial   bool
+	}{
+		{"unescaped_ampersand.xml", "Tom & Jerry's Blog", 1, "Salt & Pepper", "https://example.com/posts?id=1&ref=rss", false},
+		{"html_entities.xml", "Entities\u00a0Weekly", 2, "Café — a review", "https://example.com/cafe", false},
+		{"bom_whitespace.xml", "BOM Feed", 1, "After the BOM", "https://example.com/bom", false},
+		{"truncated.xml", "Truncated", 2, "One", "https://example.com/1", true},
+		{"unclosed_html.xml", "Raw HTML", 2, "Line breaks", "https://example.com/br", false},
+		{"broken_item.xml", "Broken Item", 2, "Good", "https://example.com/good", false},
+	}
+
+	for _, tc := range cases {
+		data, err := os.ReadFile(filepath.Join("testdata", "broken", tc.file))
+		if err != nil {
+			t.Fatalf("Failed to read fixture %s: %v", tc.file, err)
+		}
+
+		feed, err := rss.ParseFeed(data)
+		if err != nil {
+			t.Errorf("%s: expected ParseFeed to recover, got error: %v", tc.file, err)
+			continue
+		}
+
+		if feed.Channel.Title != tc.title {
+			t.Errorf("%s: expected title %q, got %q", tc.file, tc.title, feed.Channel.Title)
+		}
+		if len(feed.Channel.Item) != tc.items {
+			t.Errorf("%s: expected %d items, got %d", tc.file, tc.items, len(feed.Channel.Item))
+			continue
+		}
+		if got := feed.Channel.Item[0]; got.Title != tc.firstItem || got.Link != tc.firstLink {
+			t.Errorf("%s: unexpected first item %q (%s)", tc.file, got.Title, got.Link)
+		}
+		if feed.Partial != tc.partial {
+			t.Errorf("%s: expected Partial %v, got %v", tc.file, tc.partial, feed.Partial)
+		}
+	}
+}
+
+// TestParseFeedUnrecoverable checks that documents without any channel
+// content still fail.
+func TestParseFeedUnrecoverable(t *testing.T) {
+	for _, data := range []string{
+		"<rss><channel><title>broken",
+		"",
+	} {
+		if _, err := rss.ParseFeed([]byte(data)); err == nil {
+			t.Errorf("Expected error for %q, got nil", data)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eefret/gator/internal/database"
+)
+
+// minFeedInterval and maxFeedInterval bound the per-feed fetch interval
+// feed-edit accepts. The interval is stored in seconds as a 32-bit integer.
+const (
+	minFeedInterval = time.Minute
+	maxFeedInterval = 30 * 24 * time.Hour
+)
+
+// handleFeedEdit changes a feed's settings:
+// feed-edit <url> [--name N] [--url U] [--interval D|default] [--enabled true|false].
+func handleFeedEdit(s *State, cmd Command, user database.User, feed database.Feed) error {
+	usage := fmt.Errorf("usage: %s <url> [--name <name>] [--url <url>] [--interval <duration>|default] [--enabled true|false]", cmd.Name)
+
+	params := database.UpdateFeedParams{ID: feed.ID}
+	args := cmd.Arguments[1:]
+	if len(args) == 0 {
+		return usage
+	}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if i+1 >= len(args) {
+			return fmt.Errorf("%s requires a value", arg)
+		}
+		i++
+		value := args[i]
+
+		switch arg {
+		case "--name":
+			name := strings.TrimSpace(value)
+			if name == "" {
+				return fmt.Errorf("Feed name cannot be empty")
+			}
+			params.Name = sql.NullString{String: name, Valid: true}
+		case "--url":
+			if err := validateFeedURL(value); err != nil {
+				return err
+			}
+			if value != feed.Url {
+				params.Url = sql.NullString{String: value, Valid: true}
+			}
+		case "--interval":
+			params.SetInterval = true
+			if value == "default" {
+				continue
+			}
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("Invalid interval: %s", value)
+			}
+			if d < minFeedInterval {
+				return fmt.Errorf("Interval must be at least %s", minFeedInterval)
+			}
+			if d > maxFeedInterval {
+				return fmt.Errorf("Interval must be at most %s", maxFeedInterval)
+			}
+			params.FetchIntervalSeconds = sql.NullInt32{Int32: int32(d / time.Second), Valid: true}
+		case "--enabled":
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("Invalid --enabled value: %s", value)
+			}
+			params.Enabled = sql.NullBool{Bool: enabled, Valid: true}
+		default:
+			return usage
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var updated database.Feed
+	err := s.db.InTx(ctx, func(q database.Querier) error {
+		var err error
+		updated, err = q.UpdateFeed(ctx, params)
+		if database.IsUniqueViolation(err) {
+			return fmt.Errorf("Feed %s already exists", params.Url.String)
+		}
+		if err != nil {
+			return fmt.Errorf("Error updating feed: %v", err)
+		}
+
+		if params.Url.Valid {
+			_, err = q.CreateFeedURLChange(ctx, database.CreateFeedURLChangeParams{
+				FeedID: feed.ID,
+				OldUrl: feed.Url,
+				NewUrl: updated.Url,
+				Reason: moveReasonEdit,
+			})
+			if err != nil {
+				return fmt.Errorf("Error recording url change: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	interval := "default"
+	if updated.FetchIntervalSeconds.Valid {
+		interval = (time.Duration(updated.FetchIntervalSeconds.Int32) * time.Second).String()
+	}
+	fmt.Printf("Updated feed %s\n", updated.Name)
+	fmt.Printf("URL: %s\n", updated.Url)
+	fmt.Printf("Interval: %s\n", interval)
+	fmt.Printf("Enabled: %t\n", updated.Enabled)
+
+	return nil
+}
+
+// validateFeedURL checks that raw is an absolute http or https URL.
+func validateFeedURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("Invalid feed url %q: must be an absolute http or https url", raw)
+	}
+	return nil
+}
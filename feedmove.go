@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/eefret/gator/external/rss"
+	"github.com/eefret/gator/internal/database"
+)
+
+// Reasons recorded in feed_url_history.
+const (
+	moveReasonRedirect   = "permanent_redirect"
+	moveReasonNewFeedURL = "itunes_new_feed_url"
+	moveReasonAtomSelf   = "atom_self"
+	moveReasonEdit       = "edit"
+)
+
+// feedMove is a new address detected for a feed.
+type feedMove struct {
+	url    string
+	reason string
+	// verified is set when the new address has already served the feed, as
+	// with redirects. Addresses declared in the document are fetched first.
+	verified bool
+}
+
+// detectFeedMove looks for a permanent move of feed in a fetched document.
+// Observed redirects win over moves declared by the feed itself.
+func detectFeedMove(feed database.Feed, data *rss.RSSFeed) (feedMove, bool) {
+	candidates := []feedMove{
+		{url: data.PermanentURL, reason: moveReasonRedirect, verified: true},
+		{url: strings.TrimSpace(data.Channel.NewFeedURL), reason: moveReasonNewFeedURL},
+		{url: data.SelfURL(), reason: moveReasonAtomSelf},
+	}
+
+	for _, move := range candidates {
+		if move.url == "" || move.url == feed.Url {
+			continue
+		}
+
+		u, err := url.Parse(move.url)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			continue
+		}
+
+		return move, true
+	}
+
+	return feedMove{}, false
+}
+
+// followFeedMove points feed at its new address and records the change. If
+// another feed already uses that address, feed is merged into it: follows,
+// posts and history move over and feed is deleted. It returns the feed that
+// now owns the new address.
+func followFeedMove(ctx context.Context, db database.Store, feed database.Feed, move feedMove) (database.Feed, error) {
+	if !move.verified {
+		history, err := db.GetFeedURLHistory(ctx, feed.ID)
+		if err != nil {
+			return feed, fmt.Errorf("Error getting url history: %v", err)
+		}
+		// A feed whose self link points back to an address it already moved
+		// away from would otherwise flip between the two forever.
+		for _, change := range history {
+			if change.OldUrl == move.url {
+				return feed, fmt.Errorf("%s was a previous address of this feed", move.url)
+			}
+		}
+
+		if _, err := rss.FetchFeed(ctx, move.url); err != nil {
+			return feed, fmt.Errorf("new address does not serve a feed: %w", err)
+		}
+	}
+
+	moved := feed
+	merged := false
+	err := db.InTx(ctx, func(q database.Querier) error {
+		existing, err := q.GetFeedByURL(ctx, move.url)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			if err := q.UpdateFeedURL(ctx, database.UpdateFeedURLParams{ID: feed.ID, Url: move.url}); err != nil {
+				return fmt.Errorf("Error updating feed url: %v", err)
+			}
+			moved.Url = move.url
+		case err != nil:
+			return fmt.Errorf("Error getting feed: %v", err)
+		default:
+			if err := mergeFeed(ctx, q, feed, existing); err != nil {
+				return err
+			}
+			moved = existing
+			merged = true
+		}
+
+		_, err = q.CreateFeedURLChange(ctx, database.CreateFeedURLChangeParams{
+			FeedID: moved.ID,
+			OldUrl: feed.Url,
+			NewUrl: move.url,
+			Reason: move.reason,
+		})
+		if err != nil {
+			return fmt.Errorf("Error recording url change: %v", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return feed, err
+	}
+
+	slog.Info("Feed moved",
+		"feed_id", moved.ID,
+		"old_url", feed.Url,
+		"new_url", move.url,
+		"reason", move.reason,
+		"merged", merged,
+	)
+
+	return moved, nil
+}
+
+// mergeFeed moves everything attached to from over to into and deletes from.
+func mergeFeed(ctx context.Context, q database.Querier, from, into database.Feed) error {
+	if err := q.MoveFeedFollows(ctx, database.MoveFeedFollowsParams{ToFeedID: into.ID, FromFeedID: from.ID}); err != nil {
+		return fmt.Errorf("Error moving follows: %v", err)
+	}
+	if err := q.MoveFollowTags(ctx, database.MoveFollowTagsParams{ToFeedID: into.ID, FromFeedID: from.ID}); err != nil {
+		return fmt.Errorf("Error moving follow tags: %v", err)
+	}
+	if err := q.MovePosts(ctx, database.MovePostsParams{ToFeedID: into.ID, FromFeedID: from.ID}); err != nil {
+		return fmt.Errorf("Error moving posts: %v", err)
+	}
+	if err := q.MoveFeedFetches(ctx, database.MoveFeedFetchesParams{ToFeedID: into.ID, FromFeedID: from.ID}); err != nil {
+		return fmt.Errorf("Error moving fetch log: %v", err)
+	}
+	if err := q.MoveFeedURLHistory(ctx, database.MoveFeedURLHistoryParams{ToFeedID: into.ID, FromFeedID: from.ID}); err != nil {
+		return fmt.Errorf("Error moving url history: %v", err)
+	}
+	if err := q.MovePrunedPosts(ctx, database.MovePrunedPostsParams{ToFeedID: into.ID, FromFeedID: from.ID}); err != nil {
+		return fmt.Errorf("Error moving pruned posts: %v", err)
+	}
+	if err := q.MoveFilterRules(ctx, database.MoveFilterRulesParams{ToFeedID: into.ID, FromFeedID: from.ID}); err != nil {
+		return fmt.Errorf("Error moving filters: %v", err)
+	}
+	if err := q.MoveWebhooks(ctx, database.MoveWebhooksParams{ToFeedID: into.ID, FromFeedID: from.ID}); err != nil {
+		return fmt.Errorf("Error moving webhooks: %v", err)
+	}
+	if err := q.MarkFeedFetched(ctx, into.ID); err != nil {
+		return fmt.Errorf("Error marking feed fetched: %v", err)
+	}
+	if err := q.DeleteFeed(ctx, from.ID); err != nil {
+		return fmt.Errorf("Error deleting merged feed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/eefret/gator/internal/database"
+	"github.com/eefret/gator/internal/feedref"
+	"github.com/google/uuid"
+)
+
+// resolveFeed finds the feed ref refers to: its URL, a prefix of its ID or
+// its name. See feedref.Resolve.
+func resolveFeed(ctx context.Context, s *State, ref string) (database.Feed, error) {
+	return findFeed(ctx, s, ref, feedref.Resolve)
+}
+
+// resolveFeedExact is resolveFeed for commands that change or delete the
+// feed: names must match whole. See feedref.ResolveExact.
+func resolveFeedExact(ctx context.Context, s *State, ref string) (database.Feed, error) {
+	return findFeed(ctx, s, ref, feedref.ResolveExact)
+}
+
+func findFeed(ctx context.Context, s *State, ref string, resolve func(string, []feedref.Feed) (feedref.Feed, error)) (database.Feed, error) {
+	if feedref.IsURL(ref) {
+		feed, err := s.db.GetFeedByURL(ctx, ref)
+		if errors.Is(err, sql.ErrNoRows) {
+			return feed, fmt.Errorf("Feed %s not found", ref)
+		}
+		if err != nil {
+			return feed, fmt.Errorf("Error getting feed: %v", err)
+		}
+		return feed, nil
+	}
+
+	feeds, err := s.db.GetFeeds(ctx)
+	if err != nil {
+		return database.Feed{}, fmt.Errorf("Error getting feeds: %v", err)
+	}
+
+	byID := make(map[uuid.UUID]database.Feed, len(feeds))
+	candidates := make([]feedref.Feed, 0, len(feeds))
+	for _, feed := range feeds {
+		byID[feed.ID] = feed
+		candidates = append(candidates, feedref.Feed{ID: feed.ID, Name: feed.Name, URL: feed.Url})
+	}
+
+	match, err := resolve(ref, candidates)
+	if err != nil {
+		return database.Feed{}, feedRefError(ref, err)
+	}
+	return byID[match.ID], nil
+}
+
+// resolveFollowedFeed is resolveFeed limited to the feeds user follows, so
+// names only need to be unique among them. Personal display names match too.
+func resolveFollowedFeed(ctx context.Context, s *State, user database.User, ref string) (database.GetFeedFollowsForUserRow, error) {
+	follows, err := s.db.GetFeedFollowsForUser(ctx, user.ID)
+	if err != nil {
+		return database.GetFeedFollowsForUserRow{}, fmt.Errorf("Error getting follows: %v", err)
+	}
+
+	byFeed := make(map[uuid.UUID]database.GetFeedFollowsForUserRow, len(follows))
+	candidates := make([]feedref.Feed, 0, len(follows))
+	for _, follow := range follows {
+		byFeed[follow.FeedID] = follow
+		candidates = append(candidates, feedref.Feed{ID: follow.FeedID, Name: follow.FeedName, URL: follow.FeedUrl})
+	}
+
+	match, err := feedref.Resolve(ref, candidates)
+	if errors.Is(err, feedref.ErrNotFound) {
+		return database.GetFeedFollowsForUserRow{}, fmt.Errorf("%s is not following %s", user.Name, ref)
+	}
+	if err != nil {
+		return database.GetFeedFollowsForUserRow{}, feedRefError(ref, err)
+	}
+	return byFeed[match.ID], nil
+}
+
+// feedRefError explains why ref did not resolve, listing the candidates
+// when it was ambiguous.
+func feedRefError(ref string, err error) error {
+	var ambiguous *feedref.AmbiguousError
+	if errors.As(err, &ambiguous) {
+		var b strings.Builder
+		fmt.Fprintf(&b, "%s matches several feeds; use the URL or ID of one:", ref)
+		for _, feed := range ambiguous.Matches {
+			fmt.Fprintf(&b, "\n  %s  %s (%s)", shortID(feed.ID), feed.Name, feed.URL)
+		}
+		return errors.New(b.String())
+	}
+	if errors.Is(err, feedref.ErrNotFound) {
+		return fmt.Errorf("No feed matches %s", ref)
+	}
+	return err
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/eefret/gator/internal/output"
+)
+
+// fetchResult is the outcome of a one-shot fetch of a single feed.
+type fetchResult struct {
+	FeedName string `json:"feed_name"`
+	Url      string `json:"url"`
+	Items    int    `json:"items"`
+	NewPosts int    `json:"new_posts"`
+	Duration string `json:"duration"`
+	Error    string `json:"error"`
+}
+
+// handleFetch refreshes the given feeds, or every feed with --all, once and
+// exits. It fails when any of the fetches failed so cron and CI notice.
+func handleFetch(s *State, cmd Command) error {
+	all := len(cmd.Arguments) == 1 && cmd.Arguments[0] == "--all"
+	if !all && len(cmd.Arguments) == 0 {
+		return fmt.Errorf(`Fetch requires feed urls, ids or names, or --all. example fetch "<url>" or fetch --all`)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var refs []string
+	if all {
+		listCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		feeds, err := s.db.GetFeeds(listCtx)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("Error getting feeds: %v", err)
+		}
+
+		for _, feed := range feeds {
+			if feed.Enabled {
+				refs = append(refs, feed.Url)
+			}
+		}
+	} else {
+		refs = cmd.Arguments
+	}
+
+	// Sized so no scrape's deliveries are dropped; they finish before exit.
+	webhooks := newWebhookQueue(ctx, s.db, len(refs))
+	defer webhooks.close()
+
+	results := make([]fetchResult, 0, len(refs))
+	failed := 0
+	for _, ref := range refs {
+		if ctx.Err() != nil {
+			break
+		}
+
+		result := fetchOne(ctx, s, webhooks, ref)
+		if result.Error != "" {
+			failed++
+		}
+		results = append(results, result)
+
+		if s.Output == output.FormatText {
+			printFetchResult(result)
+		}
+	}
+
+	if s.Output != output.FormatText {
+		if err := output.Write(os.Stdout, s.Output, results); err != nil {
+			return err
+		}
+	}
+
+	if ctx.Err() != nil {
+		return fmt.Errorf("Fetch interrupted after %d of %d feeds", len(results), len(refs))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d feeds failed to fetch", failed, len(refs))
+	}
+
+	return nil
+}
+
+// fetchOne scrapes the feed ref refers to, queueing webhooks for its new
+// posts, and reports how it went.
+func fetchOne(ctx context.Context, s *State, webhooks *webhookQueue, ref string) fetchResult {
+	result := fetchResult{Url: ref}
+	started := time.Now()
+
+	lookupCtx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	feed, err := resolveFeed(lookupCtx, s, ref)
+	cancel()
+	if err != nil {
+		result.Error = err.Error()
+		result.Duration = time.Since(started).Round(time.Millisecond).String()
+		return result
+	}
+	result.FeedName = feed.Name
+	result.Url = feed.Url
+
+	scraped, err := scrapeFeed(ctx, s.db, webhooks, feed)
+	result.Items = scraped.Items
+	result.NewPosts = scraped.NewPosts
+	result.Duration = time.Since(started).Round(time.Millisecond).String()
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+func printFetchResult(result fetchResult) {
+	name := result.FeedName
+	if name == "" {
+		name = result.Url
+	}
+
+	if result.Error != "" {
+		fmt.Printf("* %s (%s): failed after %s: %s\n", name, result.Url, result.Duration, result.Error)
+		return
+	}
+
+	fmt.Printf("* %s (%s): %d new posts of %d items in %s\n", name, result.Url, result.NewPosts, result.Items, result.Duration)
+}
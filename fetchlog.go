@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/eefret/gator/internal/database"
+	"github.com/eefret/gator/internal/output"
+)
+
+// fetchLogPruneInterval is how often the aggregator prunes old fetch history.
+const fetchLogPruneInterval = time.Hour
+
+// recordFetch stores the outcome of a scrape in the fetch log. Failing to
+// record is logged but never fails the scrape itself.
+func recordFetch(ctx context.Context, db database.Store, result scrapeResult, started time.Time, scrapeErr error) {
+	// Record cancelled and timed out scrapes too, so give the insert its own
+	// deadline instead of inheriting the scrape's.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	params := database.CreateFeedFetchParams{
+		FeedID:     result.Feed.ID,
+		StartedAt:  started,
+		FinishedAt: time.Now(),
+		Bytes:      result.Bytes,
+		Items:      int32(result.Items),
+		NewPosts:   int32(result.NewPosts),
+	}
+	if result.StatusCode != 0 {
+		params.HttpStatus = sql.NullInt32{Int32: int32(result.StatusCode), Valid: true}
+	}
+	if scrapeErr != nil {
+		params.Error = sql.NullString{String: scrapeErr.Error(), Valid: true}
+	}
+
+	if _, err := db.CreateFeedFetch(ctx, params); err != nil {
+		slog.Warn("Error recording fetch", append(feedAttrs(result.Feed), "error", err)...)
+	}
+}
+
+// pruneFetchLog deletes fetch history older than retention.
+func pruneFetchLog(ctx context.Context, db database.Store, retention time.Duration) (int64, error) {
+	return db.DeleteFeedFetchesBefore(ctx, time.Now().Add(-retention))
+}
+
+func handleFetchLog(s *State, cmd Command) error {
+	if len(cmd.Arguments) > 0 && cmd.Arguments[0] == "prune" {
+		return middlewareAdmin(handleFetchLogPrune)(s, Command{Name: "fetch-log prune", Arguments: cmd.Arguments[1:]})
+	}
+
+	var feedRef string
+	limit := 20
+	for i := 0; i < len(cmd.Arguments); i++ {
+		switch arg := cmd.Arguments[i]; arg {
+		case "--limit":
+			if i+1 >= len(cmd.Arguments) {
+				return fmt.Errorf("--limit requires a value")
+			}
+			i++
+			n, err := strconv.Atoi(cmd.Arguments[i])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid limit: %s", cmd.Arguments[i])
+			}
+			limit = n
+		default:
+			if feedRef != "" {
+				return fmt.Errorf(`Fetch-log accepts at most one feed. example fetch-log "<url>" --limit 50`)
+			}
+			feedRef = arg
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var fetches []database.GetFeedFetchesRow
+	if feedRef == "" {
+		rows, err := s.db.GetFeedFetches(ctx, int32(limit))
+		if err != nil {
+			return fmt.Errorf("Error getting fetch log: %v", err)
+		}
+		fetches = rows
+	} else {
+		feed, err := resolveFeed(ctx, s, feedRef)
+		if err != nil {
+			return err
+		}
+		rows, err := s.db.GetFeedFetchesForFeed(ctx, database.GetFeedFetchesForFeedParams{
+			Url:   feed.Url,
+			Limit: int32(limit),
+		})
+		if err != nil {
+			return fmt.Errorf("Error getting fetch log: %v", err)
+		}
+		for _, row := range rows {
+			fetches = append(fetches, database.GetFeedFetchesRow(row))
+		}
+	}
+
+	if s.Output != output.FormatText {
+		return output.Write(os.Stdout, s.Output, fetches)
+	}
+
+	for _, fetch := range fetches {
+		status := "-"
+		if fetch.HttpStatus.Valid {
+			status = strconv.Itoa(int(fetch.HttpStatus.Int32))
+		}
+
+		fmt.Printf("* %s | %s (%s) | HTTP %s | %d bytes | %d items | %d new | %s\n",
+			fetch.StartedAt.Format(time.RFC3339), fetch.FeedName, fetch.FeedUrl, status,
+			fetch.Bytes, fetch.Items, fetch.NewPosts,
+			fetch.FinishedAt.Sub(fetch.StartedAt).Round(time.Millisecond))
+		if fetch.Error.Valid {
+			fmt.Printf("    Error: %s\n", fetch.Error.String)
+		}
+	}
+
+	return nil
+}
+
+// handleFetchLogPrune deletes fetch history on demand. Only admins may run it,
+// since the log covers every user's feeds.
+func handleFetchLogPrune(s *State, cmd Command, user database.User) error {
+	args := cmd.Arguments
+
+	retention, err := s.Config.FetchLogRetentionDuration()
+	if err != nil {
+		return fmt.Errorf("Error parsing fetch_log_retention: %v", err)
+	}
+
+	switch {
+	case len(args) == 2 && args[0] == "--older-than":
+		retention, err = time.ParseDuration(args[1])
+		if err != nil {
+			return fmt.Errorf("Error parsing duration: %v", err)
+		}
+	case len(args) != 0:
+		return fmt.Errorf("Fetch-log prune only accepts --older-than <duration>")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	deleted, err := pruneFetchLog(ctx, s.db, retention)
+	if err != nil {
+		return fmt.Errorf("Error pruning fetch log: %v", err)
+	}
+
+	fmt.Printf("Deleted %d fetch log entries older than %s\n", deleted, retention)
+
+	return nil
+}
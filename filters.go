@@ -0,0 +1,348 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/eefret/gator/internal/database"
+	"github.com/eefret/gator/internal/filter"
+	"github.com/eefret/gator/internal/htmltext"
+	"github.com/eefret/gator/internal/output"
+	"github.com/google/uuid"
+)
+
+// filterPageSize is how many posts are evaluated per query when applying
+// rules to existing posts.
+const filterPageSize = 500
+
+// filterRow is a rule as listed by filters list.
+type filterRow struct {
+	ID      uuid.UUID `json:"id"`
+	Action  string    `json:"action"`
+	Field   string    `json:"field"`
+	Pattern string    `json:"pattern"`
+	Regex   bool      `json:"regex"`
+	Scope   string    `json:"scope"`
+}
+
+// handleFilters dispatches the filters command family:
+//
+//	filters [list]
+//	filters add <action> <field> <pattern> [--regex] [--feed <url> | --tag <tag>]
+//	filters remove <id>
+//	filters apply [--dry-run]
+func handleFilters(s *State, cmd Command, user database.User) error {
+	sub, args := "list", cmd.Arguments
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+
+	switch sub {
+	case "list":
+		return listFilters(s, user)
+	case "add":
+		return addFilter(s, user, args)
+	case "remove":
+		return removeFilter(s, user, args)
+	case "apply":
+		return applyFilters(s, user, args)
+	}
+	return fmt.Errorf("usage: %s [list|add|remove|apply]", cmd.Name)
+}
+
+func listFilters(s *State, user database.User) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rules, err := s.db.GetFilterRulesForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("Error getting filters: %v", err)
+	}
+
+	rows := []filterRow{}
+	for _, rule := range rules {
+		scope := "all feeds"
+		switch {
+		case rule.FeedUrl.Valid:
+			scope = "feed " + rule.FeedUrl.String
+		case rule.Tag.Valid:
+			scope = "tag " + rule.Tag.String
+		}
+		rows = append(rows, filterRow{
+			ID:      rule.ID,
+			Action:  rule.Action,
+			Field:   rule.Field,
+			Pattern: rule.Pattern,
+			Regex:   rule.IsRegex,
+			Scope:   scope,
+		})
+	}
+
+	if s.Output != output.FormatText {
+		return output.Write(os.Stdout, s.Output, rows)
+	}
+
+	if len(rows) == 0 {
+		fmt.Println("No filters")
+		return nil
+	}
+	for _, row := range rows {
+		kind := "keywords"
+		if row.Regex {
+			kind = "regex"
+		}
+		fmt.Printf("%s  %-9s %s %s %q (%s)\n", shortID(row.ID), row.Action, row.Field, kind, row.Pattern, row.Scope)
+	}
+	return nil
+}
+
+func addFilter(s *State, user database.User, args []string) error {
+	usage := fmt.Errorf("usage: filters add <action> <field> <pattern> [--regex] [--feed <url> | --tag <tag>]")
+
+	var positional []string
+	var regex bool
+	var feedURL, tag string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--regex":
+			regex = true
+		case "--feed", "--tag":
+			if i+1 >= len(args) {
+				return fmt.Errorf("%s requires a value", args[i])
+			}
+			if args[i] == "--feed" {
+				feedURL = args[i+1]
+			} else {
+				normalized, err := normalizeTag(args[i+1])
+				if err != nil {
+					return err
+				}
+				tag = normalized
+			}
+			i++
+		default:
+			positional = append(positional, args[i])
+		}
+	}
+	if len(positional) != 3 || (feedURL != "" && tag != "") {
+		return usage
+	}
+
+	action, err := filter.ParseAction(positional[0])
+	if err != nil {
+		return err
+	}
+	field, err := filter.ParseField(positional[1])
+	if err != nil {
+		return err
+	}
+	rule := filter.Rule{Field: field, Pattern: positional[2], Regex: regex, Action: action, Tag: tag}
+	if _, err := filter.Compile(rule); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	params := database.CreateFilterRuleParams{
+		UserID:  user.ID,
+		Field:   string(field),
+		Pattern: rule.Pattern,
+		IsRegex: regex,
+		Action:  string(action),
+		Tag:     sql.NullString{String: tag, Valid: tag != ""},
+	}
+	if feedURL != "" {
+		follow, err := getFollow(ctx, s, user, feedURL)
+		if err != nil {
+			return err
+		}
+		params.FeedID = uuid.NullUUID{UUID: follow.FeedID, Valid: true}
+	}
+
+	created, err := s.db.CreateFilterRule(ctx, params)
+	if err != nil {
+		return fmt.Errorf("Error creating filter: %v", err)
+	}
+
+	fmt.Printf("Added filter %s: %s posts whose %s matches %q\n", shortID(created.ID), action, field, rule.Pattern)
+	fmt.Println("Run 'filters apply' to apply it to existing posts.")
+	return nil
+}
+
+func removeFilter(s *State, user database.User, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: filters remove <id>")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rules, err := s.db.GetFilterRulesForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("Error getting filters: %v", err)
+	}
+
+	ids := make([]uuid.UUID, 0, len(rules))
+	for _, rule := range rules {
+		ids = append(ids, rule.ID)
+	}
+	id, err := resolveIDPrefix("filter", args[0], ids)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.db.DeleteFilterRule(ctx, database.DeleteFilterRuleParams{UserID: user.ID, ID: id}); err != nil {
+		return fmt.Errorf("Error removing filter: %v", err)
+	}
+
+	fmt.Printf("Removed filter %s\n", shortID(id))
+	return nil
+}
+
+// applyFilters evaluates the user's rules against every post in the feeds
+// they follow, storing mark-read and save actions.
+func applyFilters(s *State, user database.User, args []string) error {
+	dryRun := false
+	for _, arg := range args {
+		if arg != "--dry-run" {
+			return fmt.Errorf("usage: filters apply [--dry-run]")
+		}
+		dryRun = true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	rules, tags, err := loadFilters(ctx, s, user)
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		fmt.Println("No filters")
+		return nil
+	}
+
+	var scanned, hidden, highlighted, markedRead, saved int
+	for offset := 0; ; offset += filterPageSize {
+		posts, err := s.db.GetPostsForUser(ctx, database.GetPostsForUserParams{
+			UserID: user.ID,
+			Limit:  filterPageSize,
+			Offset: int32(offset),
+		})
+		if err != nil {
+			return fmt.Errorf("Error getting posts: %v", err)
+		}
+
+		for _, post := range posts {
+			result := rules.Evaluate(filterPost(post), tags[post.FeedID])
+			scanned++
+			if result.Hide {
+				hidden++
+			}
+			if result.Highlight {
+				highlighted++
+			}
+			if result.MarkRead {
+				markedRead++
+			}
+			if result.Save {
+				saved++
+			}
+			if !dryRun {
+				if err := storeFilterResult(ctx, s, user, post.ID, result); err != nil {
+					return err
+				}
+			}
+		}
+
+		if len(posts) < filterPageSize {
+			break
+		}
+	}
+
+	verb := "Applied"
+	if dryRun {
+		verb = "Dry run of"
+	}
+	fmt.Printf("%s %d filters to %d posts: %d hidden, %d highlighted, %d marked read, %d saved\n",
+		verb, len(rules), scanned, hidden, highlighted, markedRead, saved)
+	return nil
+}
+
+// loadFilters compiles the user's rules and returns them with the tags of
+// each feed they follow. Rules that no longer compile are skipped.
+func loadFilters(ctx context.Context, s *State, user database.User) (filter.Set, map[uuid.UUID][]string, error) {
+	rows, err := s.db.GetFilterRulesForUser(ctx, user.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error getting filters: %v", err)
+	}
+
+	var set filter.Set
+	for _, row := range rows {
+		m, err := filter.Compile(filter.Rule{
+			ID:      row.ID,
+			Field:   filter.Field(row.Field),
+			Pattern: row.Pattern,
+			Regex:   row.IsRegex,
+			Action:  filter.Action(row.Action),
+			FeedID:  row.FeedID.UUID,
+			Tag:     row.Tag.String,
+		})
+		if err != nil {
+			slog.Warn("Skipping invalid filter", "filter_id", row.ID, "error", err)
+			continue
+		}
+		set = append(set, m)
+	}
+
+	if len(set) == 0 {
+		return nil, nil, nil
+	}
+
+	tags, err := followTags(ctx, s, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return set, tags, nil
+}
+
+// filterPost extracts the text rules are matched against.
+func filterPost(post database.GetPostsForUserRow) filter.Post {
+	var description []string
+	for _, text := range []sql.NullString{post.Description, post.Content} {
+		if text.Valid {
+			description = append(description, htmltext.Render(text.String, 1<<20))
+		}
+	}
+
+	return filter.Post{
+		FeedID:      post.FeedID,
+		Title:       post.Title,
+		Description: strings.Join(description, "\n"),
+		Author:      post.Author.String,
+		Categories:  post.Categories,
+	}
+}
+
+// storeFilterResult persists the actions that outlive a browse session.
+func storeFilterResult(ctx context.Context, s *State, user database.User, postID uuid.UUID, result filter.Result) error {
+	if result.MarkRead {
+		err := s.db.MarkPostRead(ctx, database.MarkPostReadParams{UserID: user.ID, PostID: postID})
+		if err != nil {
+			return fmt.Errorf("Error marking post read: %v", err)
+		}
+	}
+	if result.Save {
+		err := s.db.SavePost(ctx, database.SavePostParams{UserID: user.ID, PostID: postID})
+		if err != nil {
+			return fmt.Errorf("Error saving post: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/eefret/gator/internal/database"
+)
+
+// handleFullContent shows or sets whether the aggregator downloads the
+// articles a feed's posts link to: full-content <url> [on|off].
+func handleFullContent(s *State, cmd Command, user database.User, feed database.Feed) error {
+	if len(cmd.Arguments) < 1 || len(cmd.Arguments) > 2 {
+		return fmt.Errorf("usage: %s <url> [on|off]", cmd.Name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if len(cmd.Arguments) == 1 {
+		fmt.Printf("Full content for %s: %s\n", feed.Name, onOff(feed.FetchFullContent))
+		return nil
+	}
+
+	var enabled bool
+	switch cmd.Arguments[1] {
+	case "on":
+		enabled = true
+	case "off":
+		enabled = false
+	default:
+		return fmt.Errorf("usage: %s <url> [on|off]", cmd.Name)
+	}
+
+	err := s.db.SetFeedFetchFullContent(ctx, database.SetFeedFetchFullContentParams{
+		ID:               feed.ID,
+		FetchFullContent: enabled,
+	})
+	if err != nil {
+		return fmt.Errorf("Error updating feed: %v", err)
+	}
+
+	fmt.Printf("Full content for %s: %s\n", feed.Name, onOff(enabled))
+	return nil
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,55 @@
+// Package archive appends records to gzip-compressed JSON Lines files.
+package archive
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"os"
+)
+
+// Writer appends one JSON document per line to a compressed file. Every
+// Writer adds a new gzip member to the end of the file, so an archive grows
+// across runs and still reads as a single stream with zcat or gzip.Reader.
+type Writer struct {
+	file *os.File
+	gz   *gzip.Writer
+	enc  *json.Encoder
+}
+
+// Open opens path for appending, creating it if needed. Archives hold user
+// data, so new files are only readable by their owner.
+func Open(path string) (*Writer, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(file)
+	return &Writer{file: file, gz: gz, enc: json.NewEncoder(gz)}, nil
+}
+
+// Write appends v as a line of JSON.
+func (w *Writer) Write(v any) error {
+	return w.enc.Encode(v)
+}
+
+// Flush writes everything written so far through to disk, so it survives
+// even if the process dies before Close.
+func (w *Writer) Flush() error {
+	if err := w.gz.Flush(); err != nil {
+		return err
+	}
+	return w.file.Sync()
+}
+
+// Close completes the gzip member and closes the file.
+func (w *Writer) Close() error {
+	if err := w.gz.Close(); err != nil {
+		w.file.Close()
+		return err
+	}
+	if err := w.file.Sync(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
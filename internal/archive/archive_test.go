@@ -0,0 +1,127 @@
+package archive_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eefret/gator/internal/archive"
+)
+
+type record struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+func writeRecords(t *testing.T, path string, records ...record) {
+	t.Helper()
+	w, err := archive.Open(path)
+	if err != nil {
+		t.Fatalf("Expected Open to succeed, got %v", err)
+	}
+	for _, r := range records {
+		if err := w.Write(r); err != nil {
+			t.Fatalf("Expected Write to succeed, got %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Expected Close to succeed, got %v", err)
+	}
+}
+
+func readRecords(t *testing.T, path string) []record {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Expected archive to exist, got %v", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("Expected a gzip stream, got %v", err)
+	}
+
+	var records []record
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("Expected a JSON line, got %q: %v", scanner.Text(), err)
+		}
+		records = append(records, r)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("Expected archive to decompress, got %v", err)
+	}
+	return records
+}
+
+// TestAppend checks that a second Writer appends to an existing archive
+// and that both runs read back as one stream.
+func TestAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "posts.jsonl.gz")
+
+	writeRecords(t, path, record{1, "first"}, record{2, "second"})
+	writeRecords(t, path, record{3, "third"})
+
+	got := readRecords(t, path)
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 records, got %d: %+v", len(got), got)
+	}
+	for i, r := range got {
+		if r.ID != i+1 {
+			t.Errorf("Expected record %d to have id %d, got %d", i, i+1, r.ID)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("Expected mode 0600, got %o", perm)
+	}
+}
+
+// TestFlush checks that flushed records can be read before Close.
+func TestFlush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "posts.jsonl.gz")
+
+	w, err := archive.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := w.Write(record{1, "first"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Expected Flush to succeed, got %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("Expected a gzip header after Flush, got %v", err)
+	}
+	line, _ := bufio.NewReader(gz).ReadString('\n')
+	if line != "{\"id\":1,\"title\":\"first\"}\n" {
+		t.Errorf("Expected the flushed record, got %q", line)
+	}
+}
+
+// TestOpenMissingDirectory checks that Open reports unusable paths.
+func TestOpenMissingDirectory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "posts.jsonl.gz")
+	if _, err := archive.Open(path); err == nil {
+		t.Errorf("Expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,211 @@
+package article_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eefret/gator/internal/article"
+)
+
+const articleBody = `Gators are large reptiles that live in the freshwater wetlands of the
+south-eastern United States, where they bask on riverbanks, hunt fish and
+birds, and shape the ecosystem by digging holes that hold water in the dry
+season. They are apex predators, and their nests are guarded fiercely.`
+
+var samplePage = `<!DOCTYPE html>
+<html><head><title>Gators</title><script>track()</script></head>
+<body>
+<nav><a href="/">Home</a> <a href="/about">About</a></nav>
+<div class="sidebar"><p>Subscribe to our newsletter for weekly updates, deals and more.</p></div>
+<article class="post-content">
+  <h1>All about gators</h1>
+  <p>` + articleBody + `</p>
+  <p>Their jaws are strong, their teeth numerous, and their patience legendary among naturalists.</p>
+</article>
+<div id="comments"><p>First! Great article, thanks for writing it, really enjoyed it.</p></div>
+<footer><p>Copyright, all rights reserved, Example Media Group, 2024.</p></footer>
+</body></html>`
+
+// TestExtract checks that the article body is kept and page furniture dropped.
+func TestExtract(t *testing.T) {
+	got := article.Extract(samplePage)
+
+	for _, want := range []string{"All about gators", "apex predators", "patience legendary"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected extraction to contain %q, got %q", want, got)
+		}
+	}
+	for _, unwanted := range []string{"newsletter", "First!", "Copyright", "track()", "About"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("Expected extraction to drop %q, got %q", unwanted, got)
+		}
+	}
+}
+
+// TestExtractNoArticle checks that pages without real prose yield nothing.
+func TestExtractNoArticle(t *testing.T) {
+	page := `<html><body><nav><a href="/a">A</a></nav><p>Short.</p></body></html>`
+	if got := article.Extract(page); got != "" {
+		t.Errorf("Expected no content, got %q", got)
+	}
+}
+
+func newServer(t *testing.T, robots string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		if robots == "" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, robots)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, samplePage)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestFetch checks a plain download and extraction.
+func TestFetch(t *testing.T) {
+	server := newServer(t, "")
+
+	content, err := article.NewFetcher(article.Options{}).Fetch(context.Background(), server.URL+"/posts/gators")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(content, "apex predators") {
+		t.Errorf("Expected article content, got %q", content)
+	}
+}
+
+// TestFetchRobots checks that robots.txt rules for gator, or for everyone
+// when gator is not named, are honoured.
+func TestFetchRobots(t *testing.T) {
+	robots := `User-agent: *
+Disallow: /
+
+User-agent: gator
+Disallow: /private/
+Allow: /private/open$
+Disallow: /*.pdf
+Disallow: /*.php$
+`
+	server := newServer(t, robots)
+	fetcher := article.NewFetcher(article.Options{})
+
+	cases := []struct {
+		path    string
+		allowed bool
+	}{
+		{"/posts/gators", true},
+		{"/private/secret", false},
+		{"/private/open", true},
+		{"/private/open/more", false},
+		{"/files/paper.pdf", false},
+		{"/a.php/b.php", false},
+		{"/a.php/b", true},
+	}
+
+	for _, tc := range cases {
+		_, err := fetcher.Fetch(context.Background(), server.URL+tc.path)
+		if disallowed := errors.Is(err, article.ErrDisallowed); disallowed == tc.allowed {
+			t.Errorf("%s: expected allowed=%v, got error %v", tc.path, tc.allowed, err)
+		}
+	}
+
+	other := article.NewFetcher(article.Options{UserAgent: "otherbot"})
+	if _, err := other.Fetch(context.Background(), server.URL+"/posts/gators"); !errors.Is(err, article.ErrDisallowed) {
+		t.Errorf("Expected wildcard group to disallow otherbot, got %v", err)
+	}
+}
+
+// TestFetchRedirectRobots checks that redirects are held to robots.txt, so an
+// allowed page cannot forward gator to a disallowed one.
+func TestFetchRedirectRobots(t *testing.T) {
+	server := newServer(t, "User-agent: *\nDisallow: /private/\n")
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		http.Redirect(w, r, server.URL+r.URL.Path, http.StatusFound)
+	}))
+	defer redirect.Close()
+
+	fetcher := article.NewFetcher(article.Options{})
+	if _, err := fetcher.Fetch(context.Background(), redirect.URL+"/posts/gators"); err != nil {
+		t.Errorf("Expected allowed redirect to succeed, got %v", err)
+	}
+	if _, err := fetcher.Fetch(context.Background(), redirect.URL+"/private/secret"); !errors.Is(err, article.ErrDisallowed) {
+		t.Errorf("Expected redirect to a disallowed page to fail, got %v", err)
+	}
+}
+
+// TestFetchRobotsRetry checks that a failing robots.txt disallows its host
+// only until it is retried, instead of for the fetcher's lifetime.
+func TestFetchRobotsRetry(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			if failing.Load() {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, samplePage)
+	}))
+	defer server.Close()
+
+	fetcher := article.NewFetcher(article.Options{RobotsRetry: 50 * time.Millisecond})
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/posts/gators"); !errors.Is(err, article.ErrDisallowed) {
+		t.Fatalf("Expected an unavailable robots.txt to disallow, got %v", err)
+	}
+
+	failing.Store(false)
+	time.Sleep(100 * time.Millisecond)
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/posts/gators"); err != nil {
+		t.Errorf("Expected robots.txt to be retried, got %v", err)
+	}
+}
+
+// TestFetchTooLarge checks that oversized pages are refused.
+func TestFetchTooLarge(t *testing.T) {
+	server := newServer(t, "")
+
+	_, err := article.NewFetcher(article.Options{MaxBodySize: 100}).Fetch(context.Background(), server.URL+"/posts/gators")
+	if err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("Expected size limit error, got %v", err)
+	}
+}
+
+// TestFetchNotHTML checks that non-HTML links are skipped.
+func TestFetchNotHTML(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "audio/mpeg")
+		w.Write([]byte("ID3"))
+	}))
+	defer server.Close()
+
+	_, err := article.NewFetcher(article.Options{}).Fetch(context.Background(), server.URL+"/episode.mp3")
+	if err == nil || !strings.Contains(err.Error(), "not HTML") {
+		t.Errorf("Expected content type error, got %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+// Package article downloads the web page a post links to and extracts its
+// main text, readability style, for feeds that only publish teasers.
+package article
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/eefret/gator/internal/htmltext"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// minArticleLength is the shortest extracted text accepted as an article.
+const minArticleLength = 200
+
+var (
+	negativeHint = regexp.MustCompile(`(?i)comment|footer|footnote|nav|sidebar|sponsor|advert|\bads?\b|share|social|related|promo|menu|banner|cookie|popup|widget|subscribe`)
+	positiveHint = regexp.MustCompile(`(?i)article|content|entry|main|post|story|text|body|blog`)
+)
+
+// clutter are elements never part of the article body.
+var clutter = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Nav:      true,
+	atom.Header:   true,
+	atom.Footer:   true,
+	atom.Aside:    true,
+	atom.Form:     true,
+	atom.Iframe:   true,
+	atom.Button:   true,
+	atom.Svg:      true,
+}
+
+// Extract returns the sanitized HTML of the main content of a page, or ""
+// if nothing looks like an article.
+func Extract(page string) string {
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		return ""
+	}
+
+	removeClutter(doc)
+
+	scores := map[*html.Node]float64{}
+	walk(doc, func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			return
+		}
+		switch n.DataAtom {
+		case atom.P, atom.Pre, atom.Td, atom.Blockquote:
+		default:
+			return
+		}
+
+		text := strings.TrimSpace(textContent(n))
+		if len(text) < 25 {
+			return
+		}
+
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+		if parent := n.Parent; parent != nil {
+			scores[parent] += score
+			if grandparent := parent.Parent; grandparent != nil {
+				scores[grandparent] += score / 2
+			}
+		}
+	})
+
+	var best *html.Node
+	bestScore := 0.0
+	for n, score := range scores {
+		score = (score + classWeight(n)) * (1 - linkDensity(n))
+		if n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+			score += 25
+		}
+		if score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+
+	if best == nil || len(strings.TrimSpace(textContent(best))) < minArticleLength {
+		return ""
+	}
+
+	var b strings.Builder
+	for c := best.FirstChild; c != nil; c = c.NextSibling {
+		html.Render(&b, c)
+	}
+
+	return strings.TrimSpace(htmltext.Sanitize(b.String()))
+}
+
+// removeClutter detaches clutter elements and elements whose class or id
+// marks them as page furniture.
+func removeClutter(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.ElementNode && (clutter[c.DataAtom] || (classWeight(c) < 0 && c.DataAtom != atom.Body && c.DataAtom != atom.Html)) {
+			n.RemoveChild(c)
+		} else {
+			removeClutter(c)
+		}
+		c = next
+	}
+}
+
+// classWeight scores an element by the hints in its class and id.
+func classWeight(n *html.Node) float64 {
+	weight := 0.0
+	for _, attr := range n.Attr {
+		if attr.Key != "class" && attr.Key != "id" {
+			continue
+		}
+		if negativeHint.MatchString(attr.Val) {
+			weight -= 25
+		}
+		if positiveHint.MatchString(attr.Val) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+// linkDensity is the share of an element's text that sits inside links.
+func linkDensity(n *html.Node) float64 {
+	total := len(textContent(n))
+	if total == 0 {
+		return 0
+	}
+
+	linked := 0
+	walk(n, func(c *html.Node) {
+		if c.Type == html.ElementNode && c.DataAtom == atom.A {
+			linked += len(textContent(c))
+		}
+	})
+
+	return math.Min(float64(linked)/float64(total), 1)
+}
+
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	walk(n, func(c *html.Node) {
+		if c.Type == html.TextNode {
+			b.WriteString(c.Data)
+		}
+	})
+	return b.String()
+}
+
+func walk(n *html.Node, fn func(*html.Node)) {
+	fn(n)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, fn)
+	}
+}
@@ -0,0 +1,234 @@
+package article
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	// DefaultTimeout bounds a whole article download.
+	DefaultTimeout = 10 * time.Second
+	// DefaultMaxBodySize is the largest page downloaded for extraction.
+	DefaultMaxBodySize = 2 << 20
+	// DefaultUserAgent identifies gator to web servers and robots.txt.
+	DefaultUserAgent = "gator"
+
+	// DefaultRobotsRetry is how long an unreachable robots.txt keeps its host
+	// disallowed before it is requested again.
+	DefaultRobotsRetry = 5 * time.Minute
+
+	// maxRobotsSize caps robots.txt downloads, as RFC 9309 allows.
+	maxRobotsSize = 500 << 10
+	// robotsTTL is how long a fetched robots.txt is trusted.
+	robotsTTL = 24 * time.Hour
+	// maxRedirects matches the net/http default.
+	maxRedirects = 10
+)
+
+// ErrDisallowed is returned for pages robots.txt forbids fetching.
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// ErrNotArticle is returned for pages without extractable main content.
+var ErrNotArticle = errors.New("no article content found")
+
+// Options configure a Fetcher. Zero values select the defaults above.
+type Options struct {
+	Timeout     time.Duration
+	MaxBodySize int64
+	UserAgent   string
+	RobotsRetry time.Duration
+}
+
+// Fetcher downloads linked articles and extracts their main content. It
+// caches robots.txt per host for a day, or for RobotsRetry when it could not
+// be fetched.
+type Fetcher struct {
+	http        *http.Client
+	robotsHTTP  *http.Client
+	maxBodySize int64
+	userAgent   string
+	robotsRetry time.Duration
+
+	mu     sync.Mutex
+	robots map[string]robotsEntry
+}
+
+// robotsEntry is a cached robots.txt and when it must be fetched again.
+type robotsEntry struct {
+	rules   robotsRules
+	expires time.Time
+}
+
+// DefaultFetcher is used by the aggregator.
+var DefaultFetcher = NewFetcher(Options{})
+
+// NewFetcher returns a Fetcher configured by opts.
+func NewFetcher(opts Options) *Fetcher {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultMaxBodySize
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = DefaultUserAgent
+	}
+	if opts.RobotsRetry <= 0 {
+		opts.RobotsRetry = DefaultRobotsRetry
+	}
+
+	f := &Fetcher{
+		robotsHTTP:  &http.Client{Timeout: opts.Timeout},
+		maxBodySize: opts.MaxBodySize,
+		userAgent:   opts.UserAgent,
+		robotsRetry: opts.RobotsRetry,
+		robots:      map[string]robotsEntry{},
+	}
+	f.http = &http.Client{Timeout: opts.Timeout, CheckRedirect: f.checkRedirect}
+	return f
+}
+
+// Fetch downloads the page at rawURL and returns its extracted main content
+// as sanitized HTML.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid article URL %q", rawURL)
+	}
+
+	if err := f.checkRobots(ctx, u); err != nil {
+		return "", err
+	}
+
+	resp, err := f.get(ctx, f.http, rawURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("unexpected HTTP status %s from %s", resp.Status, rawURL)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "" && mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return "", fmt.Errorf("article %s is %s, not HTML", rawURL, mediaType)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBodySize+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(body)) > f.maxBodySize {
+		return "", fmt.Errorf("article %s is larger than %d bytes", rawURL, f.maxBodySize)
+	}
+
+	r, err := charset.NewReader(bytes.NewReader(body), contentType)
+	if err != nil {
+		return "", err
+	}
+	page, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	content := Extract(string(page))
+	if content == "" {
+		return "", ErrNotArticle
+	}
+	return content, nil
+}
+
+// checkRobots returns ErrDisallowed when robots.txt forbids fetching u.
+func (f *Fetcher) checkRobots(ctx context.Context, u *url.URL) error {
+	rules, err := f.robotsFor(ctx, u)
+	if err != nil {
+		return err
+	}
+	if !rules.allowed(robotsPath(u)) {
+		return ErrDisallowed
+	}
+	return nil
+}
+
+// checkRedirect applies robots.txt to every hop of an article download, so
+// an allowed page cannot redirect to a disallowed one.
+func (f *Fetcher) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	return f.checkRobots(req.Context(), req.URL)
+}
+
+// robotsFor returns the robots.txt rules for u's host. A missing robots.txt
+// allows everything; an unreachable one disallows everything, per RFC 9309,
+// until it is retried.
+func (f *Fetcher) robotsFor(ctx context.Context, u *url.URL) (robotsRules, error) {
+	origin := u.Scheme + "://" + u.Host
+
+	f.mu.Lock()
+	entry, ok := f.robots[origin]
+	f.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.rules, nil
+	}
+
+	entry = robotsEntry{expires: time.Now().Add(robotsTTL)}
+	resp, err := f.get(ctx, f.robotsHTTP, origin+"/robots.txt")
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		entry.rules = robotsRules{{allow: false, pattern: "/"}}
+		entry.expires = time.Now().Add(f.robotsRetry)
+	} else {
+		defer resp.Body.Close()
+		switch {
+		case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+			data, _ := io.ReadAll(io.LimitReader(resp.Body, maxRobotsSize))
+			entry.rules = parseRobots(string(data), f.userAgent)
+		case resp.StatusCode >= 400 && resp.StatusCode <= 499:
+			entry.rules = robotsRules{}
+		default:
+			entry.rules = robotsRules{{allow: false, pattern: "/"}}
+			entry.expires = time.Now().Add(f.robotsRetry)
+		}
+	}
+
+	f.mu.Lock()
+	f.robots[origin] = entry
+	f.mu.Unlock()
+
+	return entry.rules, nil
+}
+
+func (f *Fetcher) get(ctx context.Context, client *http.Client, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+	return client.Do(req)
+}
+
+// robotsPath is the part of u robots.txt rules are matched against.
+func robotsPath(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return path
+}
@@ -0,0 +1,125 @@
+package article
+
+import (
+	"bufio"
+	"strings"
+)
+
+// robotsRule is a single Allow or Disallow line.
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// robotsRules are the rules of the robots.txt group that applies to us.
+type robotsRules []robotsRule
+
+// parseRobots returns the rules for the first group naming agent, or the
+// "*" group when none does.
+func parseRobots(data, agent string) robotsRules {
+	agent = strings.ToLower(agent)
+
+	type group struct {
+		agents []string
+		rules  robotsRules
+	}
+
+	var groups []*group
+	var cur *group
+	inAgents := false
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				cur = &group{}
+				groups = append(groups, cur)
+				inAgents = true
+			}
+			cur.agents = append(cur.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inAgents = false
+			if cur == nil || value == "" {
+				// An empty Disallow allows everything.
+				continue
+			}
+			cur.rules = append(cur.rules, robotsRule{allow: key == "allow", pattern: value})
+		default:
+			inAgents = false
+		}
+	}
+
+	var wildcard robotsRules
+	for _, g := range groups {
+		for _, a := range g.agents {
+			if a == "*" {
+				if wildcard == nil {
+					wildcard = append(robotsRules{}, g.rules...)
+				}
+				continue
+			}
+			if strings.Contains(agent, a) {
+				return g.rules
+			}
+		}
+	}
+	return wildcard
+}
+
+// allowed reports whether path may be fetched. The longest matching rule
+// wins, and Allow wins ties, as in RFC 9309.
+func (r robotsRules) allowed(path string) bool {
+	best := -1
+	allow := true
+	for _, rule := range r {
+		if !matchRobots(rule.pattern, path) {
+			continue
+		}
+		if n := len(rule.pattern); n > best || (n == best && rule.allow) {
+			best = n
+			allow = rule.allow
+		}
+	}
+	return allow
+}
+
+// matchRobots matches path against a robots.txt pattern, where * matches any
+// run of characters and a trailing $ anchors the end.
+func matchRobots(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	if len(parts) == 1 {
+		return !anchored || rest == ""
+	}
+
+	// Middle parts match at their first occurrence, which leaves the most
+	// room for the rest; an anchored last part must end the path.
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+
+	if anchored {
+		return strings.HasSuffix(rest, last)
+	}
+	return strings.Contains(rest, last)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 const configFileName = ".gatorconfig.json"
@@ -13,6 +14,72 @@ const configFileName = ".gatorconfig.json"
 type Config struct {
 	DbURL           string `json:"db_url"`
 	CurrentUserName string `json:"current_user_name,omitempty"`
+	// MetricsAddr is the listen address for the aggregator's Prometheus
+	// endpoint, e.g. "localhost:9090". Metrics are disabled when empty.
+	MetricsAddr string `json:"metrics_addr,omitempty"`
+	// LogLevel is one of debug, info, warn or error. Defaults to info.
+	LogLevel string `json:"log_level,omitempty"`
+	// LogFormat is either text or json. Defaults to text.
+	LogFormat string `json:"log_format,omitempty"`
+	// LogFile is a path to append logs to. Defaults to stderr.
+	LogFile string `json:"log_file,omitempty"`
+	// FetchLogRetention is how long fetch history is kept, as a Go duration
+	// such as "720h". Defaults to DefaultFetchLogRetention.
+	FetchLogRetention string `json:"fetch_log_retention,omitempty"`
+	// FetchTimeout bounds each feed download, as a Go duration such as "15s".
+	FetchTimeout string `json:"fetch_timeout,omitempty"`
+	// MaxFeedBytes is the largest feed document accepted, after decompression.
+	MaxFeedBytes int64 `json:"max_feed_bytes,omitempty"`
+	// MaxRedirects is how many redirects are followed when fetching a feed.
+	MaxRedirects int `json:"max_redirects,omitempty"`
+	// MaxArticleBytes is the largest linked page downloaded for feeds with
+	// full content extraction enabled.
+	MaxArticleBytes int64 `json:"max_article_bytes,omitempty"`
+	// SMTPHost and SMTPPort locate the server digests are sent through. The
+	// port defaults to 587.
+	SMTPHost string `json:"smtp_host,omitempty"`
+	SMTPPort int    `json:"smtp_port,omitempty"`
+	// SMTPUsername and SMTPPassword are sent with PLAIN auth when set.
+	SMTPUsername string `json:"smtp_username,omitempty"`
+	SMTPPassword string `json:"smtp_password,omitempty"`
+	// SMTPFrom is the digest sender, e.g. "Gator <gator@example.com>".
+	SMTPFrom string `json:"smtp_from,omitempty"`
+	// DigestTextTemplate and DigestHTMLTemplate are paths to Go templates
+	// replacing the built-in digest bodies.
+	DigestTextTemplate string `json:"digest_text_template,omitempty"`
+	DigestHTMLTemplate string `json:"digest_html_template,omitempty"`
+	// RetentionDays and RetentionPosts bound how many posts each feed keeps:
+	// posts older than the days, or beyond the newest posts, are pruned.
+	// Zero keeps them forever. Feeds can override both.
+	RetentionDays  int `json:"retention_days,omitempty"`
+	RetentionPosts int `json:"retention_posts,omitempty"`
+	// RetentionArchive is a gzip-compressed JSON Lines file pruned posts are
+	// appended to before they are deleted.
+	RetentionArchive string `json:"retention_archive,omitempty"`
+	// PruneInterval is how often the aggregator enforces post retention, as
+	// a Go duration such as "24h". Posts are only pruned by `prune` if unset.
+	PruneInterval string `json:"prune_interval,omitempty"`
+}
+
+// DefaultFetchLogRetention is used when FetchLogRetention is not set.
+const DefaultFetchLogRetention = 30 * 24 * time.Hour
+
+// FetchLogRetentionDuration parses FetchLogRetention, falling back to
+// DefaultFetchLogRetention when it is empty.
+func (c *Config) FetchLogRetentionDuration() (time.Duration, error) {
+	if c.FetchLogRetention == "" {
+		return DefaultFetchLogRetention, nil
+	}
+	return time.ParseDuration(c.FetchLogRetention)
+}
+
+// PruneIntervalDuration parses PruneInterval, returning zero when it is
+// empty.
+func (c *Config) PruneIntervalDuration() (time.Duration, error) {
+	if c.PruneInterval == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(c.PruneInterval)
 }
 
 // Read reads the JSON configuration file located in the user's HOME directory,
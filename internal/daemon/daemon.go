@@ -0,0 +1,261 @@
+// Package daemon provides the pieces needed to run the aggregator in the
+// background: a PID lock file so only one aggregator runs at a time, and a
+// unix socket control channel used by `agg status` and `fetch-now`.
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const stateDirName = ".gator"
+
+// ErrNotRunning is returned when no aggregator holds the lock or answers on
+// the control socket.
+var ErrNotRunning = errors.New("aggregator is not running")
+
+// Paths holds the files used to coordinate with a running aggregator.
+type Paths struct {
+	PidFile string
+	Socket  string
+	LogFile string
+}
+
+// DefaultPaths returns the paths inside ~/.gator, creating the directory if
+// needed.
+func DefaultPaths() (Paths, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return Paths{}, err
+	}
+
+	dir := filepath.Join(home, stateDirName)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return Paths{}, err
+	}
+
+	return PathsIn(dir), nil
+}
+
+// PathsIn returns the coordination paths inside dir.
+func PathsIn(dir string) Paths {
+	return Paths{
+		PidFile: filepath.Join(dir, "agg.pid"),
+		Socket:  filepath.Join(dir, "agg.sock"),
+		LogFile: filepath.Join(dir, "agg.log"),
+	}
+}
+
+// Lock is an exclusive advisory lock on the PID file. The kernel releases it
+// when the process exits, so a crashed aggregator never leaves a stale lock.
+type Lock struct {
+	file *os.File
+}
+
+// AcquireLock takes the lock on path and records the current PID in it. It
+// fails if another process already holds the lock.
+func AcquireLock(path string) (*Lock, error) {
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+			f.Close()
+			if errors.Is(err, syscall.EWOULDBLOCK) {
+				pid, _ := ReadPID(path)
+				return nil, fmt.Errorf("another aggregator is already running (pid %d)", pid)
+			}
+			return nil, err
+		}
+
+		// A releasing aggregator removes the file before unlocking it, so
+		// the lock may have been taken on a file that is no longer at path.
+		// Locking that would let a second aggregator lock the new one.
+		current, err := isCurrent(f, path)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if current {
+			return writePID(f)
+		}
+		f.Close()
+	}
+}
+
+// isCurrent reports whether f is still the file at path.
+func isCurrent(f *os.File, path string) (bool, error) {
+	opened, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	linked, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(opened, linked), nil
+}
+
+// writePID records the current PID in the locked file f.
+func writePID(f *os.File) (*Lock, error) {
+	if err := f.Truncate(0); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &Lock{file: f}, nil
+}
+
+// Release removes the PID file and drops the lock. The file is removed while
+// still locked so no other process can lock it in between; AcquireLock
+// retries if it locked the file just before it was removed.
+func (l *Lock) Release() error {
+	os.Remove(l.file.Name())
+	return l.file.Close()
+}
+
+// ReadPID returns the PID recorded in path.
+func ReadPID(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// RunningPID returns the PID of the aggregator holding the lock on path, or
+// ErrNotRunning if the lock is free.
+func RunningPID(path string) (int, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, ErrNotRunning
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_SH|syscall.LOCK_NB); err == nil {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		return 0, ErrNotRunning
+	}
+
+	return ReadPID(path)
+}
+
+// Request is a command sent to the aggregator over the control socket.
+type Request struct {
+	Command string `json:"command"`
+	URL     string `json:"url,omitempty"`
+}
+
+// Response is the aggregator's reply to a Request.
+type Response struct {
+	Status  *Status `json:"status,omitempty"`
+	Message string  `json:"message,omitempty"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// Status describes the state of a running aggregator.
+type Status struct {
+	PID       int       `json:"pid"`
+	Started   time.Time `json:"started"`
+	Interval  string    `json:"interval"`
+	LastCycle time.Time `json:"last_cycle"`
+	Cycles    int       `json:"cycles"`
+	Fetched   int       `json:"fetched"`
+	Errors    int       `json:"errors"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// Handler answers control requests.
+type Handler func(ctx context.Context, req Request) Response
+
+// Listen opens the control socket at path, replacing a socket left behind by
+// a previous run. Callers must hold the PID lock first.
+func Listen(path string) (net.Listener, error) {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return net.Listen("unix", path)
+}
+
+// Serve accepts connections on l until ctx is cancelled, answering one
+// request per connection.
+func Serve(ctx context.Context, l net.Listener, handler Handler) error {
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		go func() {
+			defer conn.Close()
+
+			var req Request
+			if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+				json.NewEncoder(conn).Encode(Response{Error: fmt.Sprintf("invalid request: %v", err)})
+				return
+			}
+
+			json.NewEncoder(conn).Encode(handler(ctx, req))
+		}()
+	}
+}
+
+// Call sends req to the aggregator listening on path and waits up to timeout
+// for the response.
+func Call(path string, req Request, timeout time.Duration) (Response, error) {
+	conn, err := net.DialTimeout("unix", path, timeout)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ECONNREFUSED) {
+			return Response{}, ErrNotRunning
+		}
+		return Response{}, err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return Response{}, err
+	}
+
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return Response{}, err
+	}
+
+	if resp.Error != "" {
+		return resp, errors.New(resp.Error)
+	}
+
+	return resp, nil
+}
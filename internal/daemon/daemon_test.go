@@ -0,0 +1,126 @@
+package daemon_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eefret/gator/internal/daemon"
+)
+
+// TestAcquireLockIsExclusive verifies that a second aggregator cannot take
+// the lock while the first one holds it, and can once it is released.
+func TestAcquireLockIsExclusive(t *testing.T) {
+	paths := daemon.PathsIn(t.TempDir())
+
+	lock, err := daemon.AcquireLock(paths.PidFile)
+	if err != nil {
+		t.Fatalf("Expected first lock to succeed, got error: %v", err)
+	}
+
+	pid, err := daemon.RunningPID(paths.PidFile)
+	if err != nil {
+		t.Fatalf("Expected RunningPID to succeed, got error: %v", err)
+	}
+	if pid != os.Getpid() {
+		t.Errorf("Expected pid %d, got %d", os.Getpid(), pid)
+	}
+
+	if _, err := daemon.AcquireLock(paths.PidFile); err == nil {
+		t.Fatal("Expected second lock to fail, got nil")
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Expected Release to succeed, got error: %v", err)
+	}
+
+	if _, err := daemon.RunningPID(paths.PidFile); !errors.Is(err, daemon.ErrNotRunning) {
+		t.Errorf("Expected ErrNotRunning after release, got %v", err)
+	}
+}
+
+// TestAcquireLockRace verifies that aggregators starting while another one
+// releases the lock never end up holding it at the same time.
+func TestAcquireLockRace(t *testing.T) {
+	paths := daemon.PathsIn(t.TempDir())
+
+	var holders atomic.Int32
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 200 {
+				lock, err := daemon.AcquireLock(paths.PidFile)
+				if err != nil {
+					continue
+				}
+				if n := holders.Add(1); n != 1 {
+					t.Errorf("Expected a single lock holder, got %d", n)
+				}
+				time.Sleep(100 * time.Microsecond)
+				holders.Add(-1)
+				lock.Release()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// TestServeAndCall round-trips a request over the control socket.
+func TestServeAndCall(t *testing.T) {
+	paths := daemon.PathsIn(t.TempDir())
+
+	l, err := daemon.Listen(paths.Socket)
+	if err != nil {
+		t.Fatalf("Expected Listen to succeed, got error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go daemon.Serve(ctx, l, func(ctx context.Context, req daemon.Request) daemon.Response {
+		switch req.Command {
+		case "status":
+			return daemon.Response{Status: &daemon.Status{PID: 42, Cycles: 3}}
+		case "fetch":
+			return daemon.Response{Message: "fetched " + req.URL}
+		}
+		return daemon.Response{Error: "unknown command"}
+	})
+
+	resp, err := daemon.Call(paths.Socket, daemon.Request{Command: "status"}, time.Second)
+	if err != nil {
+		t.Fatalf("Expected status call to succeed, got error: %v", err)
+	}
+	if resp.Status == nil || resp.Status.PID != 42 || resp.Status.Cycles != 3 {
+		t.Errorf("Unexpected status: %+v", resp.Status)
+	}
+
+	resp, err = daemon.Call(paths.Socket, daemon.Request{Command: "fetch", URL: "https://example.com/rss"}, time.Second)
+	if err != nil {
+		t.Fatalf("Expected fetch call to succeed, got error: %v", err)
+	}
+	if resp.Message != "fetched https://example.com/rss" {
+		t.Errorf("Unexpected message: %q", resp.Message)
+	}
+
+	if _, err := daemon.Call(paths.Socket, daemon.Request{Command: "bogus"}, time.Second); err == nil {
+		t.Error("Expected error response for unknown command, got nil")
+	}
+}
+
+// TestCallNotRunning checks that calling a missing socket reports
+// ErrNotRunning.
+func TestCallNotRunning(t *testing.T) {
+	paths := daemon.PathsIn(t.TempDir())
+
+	_, err := daemon.Call(paths.Socket, daemon.Request{Command: "status"}, time.Second)
+	if !errors.Is(err, daemon.ErrNotRunning) {
+		t.Errorf("Expected ErrNotRunning, got %v", err)
+	}
+}
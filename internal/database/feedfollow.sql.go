@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,10 +17,10 @@ const createFeedFollow = `-- name: CreateFeedFollow :one
 WITH inserted_feed_follow AS (
     INSERT INTO feed_follows (feed_id, user_id)
     VALUES ($1, $2)
-    RETURNING id, created_at, updated_at, feed_id, user_id
+    RETURNING id, created_at, updated_at, feed_id, user_id, display_name
 )
 SELECT
-    inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.feed_id, inserted_feed_follow.user_id,
+    inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.feed_id, inserted_feed_follow.user_id, inserted_feed_follow.display_name,
     feeds.name AS feed_name,
     users.name AS user_name
 FROM inserted_feed_follow
@@ -28,18 +29,19 @@ INNER JOIN users ON users.id = inserted_feed_follow.user_id
 `
 
 type CreateFeedFollowParams struct {
-	FeedID uuid.UUID
-	UserID uuid.UUID
+	FeedID uuid.UUID `json:"feed_id"`
+	UserID uuid.UUID `json:"user_id"`
 }
 
 type CreateFeedFollowRow struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	FeedID    uuid.UUID
-	UserID    uuid.UUID
-	FeedName  string
-	UserName  string
+	ID          uuid.UUID      `json:"id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	FeedID      uuid.UUID      `json:"feed_id"`
+	UserID      uuid.UUID      `json:"user_id"`
+	DisplayName sql.NullString `json:"display_name"`
+	FeedName    string         `json:"feed_name"`
+	UserName    string         `json:"user_name"`
 }
 
 func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowParams) (CreateFeedFollowRow, error) {
@@ -51,13 +53,14 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UpdatedAt,
 		&i.FeedID,
 		&i.UserID,
+		&i.DisplayName,
 		&i.FeedName,
 		&i.UserName,
 	)
 	return i, err
 }
 
-const deleteFeedFollow = `-- name: DeleteFeedFollow :exec
+const deleteFeedFollow = `-- name: DeleteFeedFollow :execrows
 DELETE FROM feed_follows ff
 USING feeds f
 WHERE ff.user_id = $1
@@ -66,19 +69,23 @@ WHERE ff.user_id = $1
 `
 
 type DeleteFeedFollowParams struct {
-	UserID uuid.UUID
-	Url    string
+	UserID uuid.UUID `json:"user_id"`
+	Url    string    `json:"url"`
 }
 
-func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowParams) error {
-	_, err := q.db.ExecContext(ctx, deleteFeedFollow, arg.UserID, arg.Url)
-	return err
+func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFeedFollow, arg.UserID, arg.Url)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
 SELECT ff.id, ff.user_id, ff.feed_id, ff.created_at, ff.updated_at,
-	       f.name AS feed_name,
-	       u.name AS user_name
+	       COALESCE(ff.display_name, f.name) AS feed_name,
+	       u.name AS user_name,
+	       f.url AS feed_url
 	FROM feed_follows ff
 	INNER JOIN feeds f ON f.id = ff.feed_id
 	INNER JOIN users u ON u.id = ff.user_id
@@ -86,13 +93,14 @@ SELECT ff.id, ff.user_id, ff.feed_id, ff.created_at, ff.updated_at,
 `
 
 type GetFeedFollowsForUserRow struct {
-	ID        uuid.UUID
-	UserID    uuid.UUID
-	FeedID    uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	FeedName  string
-	UserName  string
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	FeedID    uuid.UUID `json:"feed_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	FeedName  string    `json:"feed_name"`
+	UserName  string    `json:"user_name"`
+	FeedUrl   string    `json:"feed_url"`
 }
 
 func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]GetFeedFollowsForUserRow, error) {
@@ -112,6 +120,7 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 			&i.UpdatedAt,
 			&i.FeedName,
 			&i.UserName,
+			&i.FeedUrl,
 		); err != nil {
 			return nil, err
 		}
@@ -125,3 +134,19 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 	}
 	return items, nil
 }
+
+const setFeedFollowDisplayName = `-- name: SetFeedFollowDisplayName :exec
+UPDATE feed_follows
+SET display_name = $2, updated_at = now()
+WHERE id = $1
+`
+
+type SetFeedFollowDisplayNameParams struct {
+	ID          uuid.UUID      `json:"id"`
+	DisplayName sql.NullString `json:"display_name"`
+}
+
+func (q *Queries) SetFeedFollowDisplayName(ctx context.Context, arg SetFeedFollowDisplayNameParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFollowDisplayName, arg.ID, arg.DisplayName)
+	return err
+}
@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -22,16 +23,16 @@ VALUES (
     $5,
     $6
 )
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, fetch_full_content, retention_days, retention_posts, fetch_interval_seconds, enabled
 `
 
 type CreateFeedParams struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
-	Url       string
-	UserID    uuid.UUID
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+	Url       string    `json:"url"`
+	UserID    uuid.UUID `json:"user_id"`
 }
 
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
@@ -52,12 +53,17 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.FetchFullContent,
+		&i.RetentionDays,
+		&i.RetentionPosts,
+		&i.FetchIntervalSeconds,
+		&i.Enabled,
 	)
 	return i, err
 }
 
 const getFeedByURL = `-- name: GetFeedByURL :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds WHERE url = $1
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, fetch_full_content, retention_days, retention_posts, fetch_interval_seconds, enabled FROM feeds WHERE url = $1
 `
 
 func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
@@ -71,12 +77,17 @@ func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.FetchFullContent,
+		&i.RetentionDays,
+		&i.RetentionPosts,
+		&i.FetchIntervalSeconds,
+		&i.Enabled,
 	)
 	return i, err
 }
 
 const getFeeds = `-- name: GetFeeds :many
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, fetch_full_content, retention_days, retention_posts, fetch_interval_seconds, enabled FROM feeds
 `
 
 func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
@@ -96,6 +107,70 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
 			&i.Url,
 			&i.UserID,
 			&i.LastFetchedAt,
+			&i.FetchFullContent,
+			&i.RetentionDays,
+			&i.RetentionPosts,
+			&i.FetchIntervalSeconds,
+			&i.Enabled,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedsWithUsers = `-- name: GetFeedsWithUsers :many
+SELECT feeds.id, feeds.created_at, feeds.updated_at, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.fetch_full_content, feeds.retention_days, feeds.retention_posts, feeds.fetch_interval_seconds, feeds.enabled, users.name AS user_name FROM feeds
+INNER JOIN users ON users.id = feeds.user_id
+ORDER BY feeds.created_at ASC
+`
+
+type GetFeedsWithUsersRow struct {
+	ID                   uuid.UUID     `json:"id"`
+	CreatedAt            time.Time     `json:"created_at"`
+	UpdatedAt            time.Time     `json:"updated_at"`
+	Name                 string        `json:"name"`
+	Url                  string        `json:"url"`
+	UserID               uuid.UUID     `json:"user_id"`
+	LastFetchedAt        sql.NullTime  `json:"last_fetched_at"`
+	FetchFullContent     bool          `json:"fetch_full_content"`
+	RetentionDays        sql.NullInt32 `json:"retention_days"`
+	RetentionPosts       sql.NullInt32 `json:"retention_posts"`
+	FetchIntervalSeconds sql.NullInt32 `json:"fetch_interval_seconds"`
+	Enabled              bool          `json:"enabled"`
+	UserName             string        `json:"user_name"`
+}
+
+func (q *Queries) GetFeedsWithUsers(ctx context.Context) ([]GetFeedsWithUsersRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsWithUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFeedsWithUsersRow
+	for rows.Next() {
+		var i GetFeedsWithUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.FetchFullContent,
+			&i.RetentionDays,
+			&i.RetentionPosts,
+			&i.FetchIntervalSeconds,
+			&i.Enabled,
+			&i.UserName,
 		); err != nil {
 			return nil, err
 		}
@@ -111,11 +186,19 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
 }
 
 const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, fetch_full_content, retention_days, retention_posts, fetch_interval_seconds, enabled FROM feeds
+WHERE enabled
+  AND (
+    fetch_interval_seconds IS NULL
+    OR last_fetched_at IS NULL
+    OR last_fetched_at + make_interval(secs => fetch_interval_seconds) <= now()
+  )
 ORDER BY last_fetched_at ASC NULLS FIRST
 LIMIT 1
 `
 
+// Feeds that are disabled or fetched more recently than their own interval
+// are skipped.
 func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
 	var i Feed
@@ -127,6 +210,11 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.FetchFullContent,
+		&i.RetentionDays,
+		&i.RetentionPosts,
+		&i.FetchIntervalSeconds,
+		&i.Enabled,
 	)
 	return i, err
 }
@@ -141,3 +229,85 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
 	_, err := q.db.ExecContext(ctx, markFeedFetched, id)
 	return err
 }
+
+const setFeedFetchFullContent = `-- name: SetFeedFetchFullContent :exec
+UPDATE feeds
+SET fetch_full_content = $2, updated_at = now()
+WHERE id = $1
+`
+
+type SetFeedFetchFullContentParams struct {
+	ID               uuid.UUID `json:"id"`
+	FetchFullContent bool      `json:"fetch_full_content"`
+}
+
+func (q *Queries) SetFeedFetchFullContent(ctx context.Context, arg SetFeedFetchFullContentParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFetchFullContent, arg.ID, arg.FetchFullContent)
+	return err
+}
+
+const setFeedRetention = `-- name: SetFeedRetention :exec
+UPDATE feeds
+SET retention_days = $2, retention_posts = $3, updated_at = now()
+WHERE id = $1
+`
+
+type SetFeedRetentionParams struct {
+	ID             uuid.UUID     `json:"id"`
+	RetentionDays  sql.NullInt32 `json:"retention_days"`
+	RetentionPosts sql.NullInt32 `json:"retention_posts"`
+}
+
+func (q *Queries) SetFeedRetention(ctx context.Context, arg SetFeedRetentionParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedRetention, arg.ID, arg.RetentionDays, arg.RetentionPosts)
+	return err
+}
+
+const updateFeed = `-- name: UpdateFeed :one
+UPDATE feeds
+SET name = COALESCE($1, name),
+    url = COALESCE($2, url),
+    fetch_interval_seconds = CASE WHEN $3::bool THEN $4 ELSE fetch_interval_seconds END,
+    enabled = COALESCE($5, enabled),
+    updated_at = now()
+WHERE id = $6
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, fetch_full_content, retention_days, retention_posts, fetch_interval_seconds, enabled
+`
+
+type UpdateFeedParams struct {
+	Name                 sql.NullString `json:"name"`
+	Url                  sql.NullString `json:"url"`
+	SetInterval          bool           `json:"set_interval"`
+	FetchIntervalSeconds sql.NullInt32  `json:"fetch_interval_seconds"`
+	Enabled              sql.NullBool   `json:"enabled"`
+	ID                   uuid.UUID      `json:"id"`
+}
+
+// Fields left NULL keep their value. The interval is only changed when
+// set_interval is true, so it can also be cleared.
+func (q *Queries) UpdateFeed(ctx context.Context, arg UpdateFeedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, updateFeed,
+		arg.Name,
+		arg.Url,
+		arg.SetInterval,
+		arg.FetchIntervalSeconds,
+		arg.Enabled,
+		arg.ID,
+	)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.FetchFullContent,
+		&i.RetentionDays,
+		&i.RetentionPosts,
+		&i.FetchIntervalSeconds,
+		&i.Enabled,
+	)
+	return i, err
+}
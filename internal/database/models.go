@@ -12,37 +12,123 @@ import (
 )
 
 type Feed struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+	ID                   uuid.UUID     `json:"id"`
+	CreatedAt            time.Time     `json:"created_at"`
+	UpdatedAt            time.Time     `json:"updated_at"`
+	Name                 string        `json:"name"`
+	Url                  string        `json:"url"`
+	UserID               uuid.UUID     `json:"user_id"`
+	LastFetchedAt        sql.NullTime  `json:"last_fetched_at"`
+	FetchFullContent     bool          `json:"fetch_full_content"`
+	RetentionDays        sql.NullInt32 `json:"retention_days"`
+	RetentionPosts       sql.NullInt32 `json:"retention_posts"`
+	FetchIntervalSeconds sql.NullInt32 `json:"fetch_interval_seconds"`
+	Enabled              bool          `json:"enabled"`
+}
+
+type FeedFetch struct {
+	ID         uuid.UUID      `json:"id"`
+	FeedID     uuid.UUID      `json:"feed_id"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at"`
+	HttpStatus sql.NullInt32  `json:"http_status"`
+	Bytes      int64          `json:"bytes"`
+	Items      int32          `json:"items"`
+	NewPosts   int32          `json:"new_posts"`
+	Error      sql.NullString `json:"error"`
 }
 
 type FeedFollow struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	FeedID    uuid.UUID
-	UserID    uuid.UUID
+	ID          uuid.UUID      `json:"id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	FeedID      uuid.UUID      `json:"feed_id"`
+	UserID      uuid.UUID      `json:"user_id"`
+	DisplayName sql.NullString `json:"display_name"`
+}
+
+type FeedUrlHistory struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	FeedID    uuid.UUID `json:"feed_id"`
+	OldUrl    string    `json:"old_url"`
+	NewUrl    string    `json:"new_url"`
+	Reason    string    `json:"reason"`
+}
+
+type FilterRule struct {
+	ID        uuid.UUID      `json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UserID    uuid.UUID      `json:"user_id"`
+	Field     string         `json:"field"`
+	Pattern   string         `json:"pattern"`
+	IsRegex   bool           `json:"is_regex"`
+	Action    string         `json:"action"`
+	FeedID    uuid.NullUUID  `json:"feed_id"`
+	Tag       sql.NullString `json:"tag"`
+}
+
+type FollowTag struct {
+	FeedFollowID uuid.UUID `json:"feed_follow_id"`
+	Tag          string    `json:"tag"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
 type Post struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
+	ID          uuid.UUID      `json:"id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	Title       string         `json:"title"`
+	Url         string         `json:"url"`
+	Description sql.NullString `json:"description"`
+	PublishedAt sql.NullTime   `json:"published_at"`
+	FeedID      uuid.UUID      `json:"feed_id"`
+	Content     sql.NullString `json:"content"`
+	Author      sql.NullString `json:"author"`
+	Categories  []string       `json:"categories"`
+}
+
+type PostState struct {
+	UserID  uuid.UUID    `json:"user_id"`
+	PostID  uuid.UUID    `json:"post_id"`
+	ReadAt  sql.NullTime `json:"read_at"`
+	SavedAt sql.NullTime `json:"saved_at"`
+}
+
+type PrunedPost struct {
+	Url      string    `json:"url"`
+	FeedID   uuid.UUID `json:"feed_id"`
+	PrunedAt time.Time `json:"pruned_at"`
 }
 
 type User struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
+	ID           uuid.UUID      `json:"id"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	Name         string         `json:"name"`
+	Email        sql.NullString `json:"email"`
+	LastDigestAt sql.NullTime   `json:"last_digest_at"`
+	Role         string         `json:"role"`
+}
+
+type Webhook struct {
+	ID              uuid.UUID      `json:"id"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UserID          uuid.UUID      `json:"user_id"`
+	Url             string         `json:"url"`
+	Secret          string         `json:"secret"`
+	FeedID          uuid.NullUUID  `json:"feed_id"`
+	Tag             sql.NullString `json:"tag"`
+	Keywords        sql.NullString `json:"keywords"`
+	PayloadTemplate sql.NullString `json:"payload_template"`
+}
+
+type WebhookDelivery struct {
+	ID         uuid.UUID      `json:"id"`
+	CreatedAt  time.Time      `json:"created_at"`
+	WebhookID  uuid.UUID      `json:"webhook_id"`
+	PostID     uuid.NullUUID  `json:"post_id"`
+	Attempts   int32          `json:"attempts"`
+	StatusCode sql.NullInt32  `json:"status_code"`
+	Error      sql.NullString `json:"error"`
 }
@@ -11,28 +11,37 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createPost = `-- name: CreatePost :one
-INSERT INTO posts (title, url, description, published_at, feed_id)
-VALUES (
-    $1,
-    $2,
-    $3,
-    $4,
-    $5
+INSERT INTO posts (title, url, description, published_at, feed_id, author, categories)
+SELECT
+    $1::text,
+    $2::text,
+    $3::text,
+    $4::timestamptz,
+    $5::uuid,
+    $6::text,
+    $7::text[]
+WHERE NOT EXISTS (
+    SELECT 1 FROM pruned_posts WHERE pruned_posts.url = $2
 )
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+ON CONFLICT (url) DO NOTHING
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, content, author, categories
 `
 
 type CreatePostParams struct {
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
+	Title       string         `json:"title"`
+	Url         string         `json:"url"`
+	Description sql.NullString `json:"description"`
+	PublishedAt sql.NullTime   `json:"published_at"`
+	FeedID      uuid.UUID      `json:"feed_id"`
+	Author      sql.NullString `json:"author"`
+	Categories  []string       `json:"categories"`
 }
 
+// Posts retention pruned are not stored again.
 func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
 	row := q.db.QueryRowContext(ctx, createPost,
 		arg.Title,
@@ -40,6 +49,8 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		arg.Description,
 		arg.PublishedAt,
 		arg.FeedID,
+		arg.Author,
+		pq.Array(arg.Categories),
 	)
 	var i Post
 	err := row.Scan(
@@ -51,38 +62,48 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.Content,
+		&i.Author,
+		pq.Array(&i.Categories),
 	)
 	return i, err
 }
 
 const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, feeds.name AS feed_name FROM posts
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.content, posts.author, posts.categories, COALESCE(feed_follows.display_name, feeds.name) AS feed_name, post_states.read_at FROM posts
 JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
 JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_states ON post_states.post_id = posts.id
+  AND post_states.user_id = feed_follows.user_id
 WHERE feed_follows.user_id = $1
-ORDER BY posts.published_at DESC
-LIMIT $2
+ORDER BY posts.published_at DESC, posts.id ASC
+LIMIT $2 OFFSET $3
 `
 
 type GetPostsForUserParams struct {
-	UserID uuid.UUID
-	Limit  int32
+	UserID uuid.UUID `json:"user_id"`
+	Limit  int32     `json:"limit"`
+	Offset int32     `json:"offset"`
 }
 
 type GetPostsForUserRow struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
-	FeedName    string
+	ID          uuid.UUID      `json:"id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	Title       string         `json:"title"`
+	Url         string         `json:"url"`
+	Description sql.NullString `json:"description"`
+	PublishedAt sql.NullTime   `json:"published_at"`
+	FeedID      uuid.UUID      `json:"feed_id"`
+	Content     sql.NullString `json:"content"`
+	Author      sql.NullString `json:"author"`
+	Categories  []string       `json:"categories"`
+	FeedName    string         `json:"feed_name"`
+	ReadAt      sql.NullTime   `json:"read_at"`
 }
 
 func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -99,7 +120,11 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.Content,
+			&i.Author,
+			pq.Array(&i.Categories),
 			&i.FeedName,
+			&i.ReadAt,
 		); err != nil {
 			return nil, err
 		}
@@ -113,3 +138,96 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	}
 	return items, nil
 }
+
+const getPostsForUserByTag = `-- name: GetPostsForUserByTag :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.content, posts.author, posts.categories, COALESCE(feed_follows.display_name, feeds.name) AS feed_name, post_states.read_at FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN follow_tags ON follow_tags.feed_follow_id = feed_follows.id
+JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_states ON post_states.post_id = posts.id
+  AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND follow_tags.tag = $2
+ORDER BY posts.published_at DESC, posts.id ASC
+LIMIT $3 OFFSET $4
+`
+
+type GetPostsForUserByTagParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	Tag    string    `json:"tag"`
+	Limit  int32     `json:"limit"`
+	Offset int32     `json:"offset"`
+}
+
+type GetPostsForUserByTagRow struct {
+	ID          uuid.UUID      `json:"id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	Title       string         `json:"title"`
+	Url         string         `json:"url"`
+	Description sql.NullString `json:"description"`
+	PublishedAt sql.NullTime   `json:"published_at"`
+	FeedID      uuid.UUID      `json:"feed_id"`
+	Content     sql.NullString `json:"content"`
+	Author      sql.NullString `json:"author"`
+	Categories  []string       `json:"categories"`
+	FeedName    string         `json:"feed_name"`
+	ReadAt      sql.NullTime   `json:"read_at"`
+}
+
+func (q *Queries) GetPostsForUserByTag(ctx context.Context, arg GetPostsForUserByTagParams) ([]GetPostsForUserByTagRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUserByTag,
+		arg.UserID,
+		arg.Tag,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsForUserByTagRow
+	for rows.Next() {
+		var i GetPostsForUserByTagRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Content,
+			&i.Author,
+			pq.Array(&i.Categories),
+			&i.FeedName,
+			&i.ReadAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setPostContent = `-- name: SetPostContent :exec
+UPDATE posts
+SET content = $2, updated_at = now()
+WHERE id = $1
+`
+
+type SetPostContentParams struct {
+	ID      uuid.UUID      `json:"id"`
+	Content sql.NullString `json:"content"`
+}
+
+func (q *Queries) SetPostContent(ctx context.Context, arg SetPostContentParams) error {
+	_, err := q.db.ExecContext(ctx, setPostContent, arg.ID, arg.Content)
+	return err
+}
@@ -0,0 +1,104 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/eefret/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+const countAllData = `-- name: CountAllData :one
+SELECT
+    (SELECT count(*) FROM users) AS users,
+    (SELECT count(*) FROM feeds) AS feeds,
+    (SELECT count(*) FROM feed_follows) AS follows,
+    (SELECT count(*) FROM posts) AS posts
+`
+
+func (q *Queries) CountAllData(ctx context.Context) (database.CountAllDataRow, error) {
+	row := q.db.QueryRowContext(ctx, countAllData)
+	var i database.CountAllDataRow
+	err := row.Scan(
+		&i.Users,
+		&i.Feeds,
+		&i.Follows,
+		&i.Posts,
+	)
+	return i, err
+}
+
+const countFeedData = `-- name: CountFeedData :one
+SELECT
+    (SELECT count(*) FROM feed_follows WHERE feed_follows.feed_id = $1) AS follows,
+    (SELECT count(*) FROM posts WHERE posts.feed_id = $1) AS posts
+`
+
+func (q *Queries) CountFeedData(ctx context.Context, feedID uuid.UUID) (database.CountFeedDataRow, error) {
+	row := q.db.QueryRowContext(ctx, countFeedData, feedID)
+	var i database.CountFeedDataRow
+	err := row.Scan(&i.Follows, &i.Posts)
+	return i, err
+}
+
+const countUserData = `-- name: CountUserData :one
+SELECT
+    (SELECT count(*) FROM feeds WHERE feeds.user_id = $1) AS feeds,
+    (SELECT count(*) FROM feed_follows WHERE feed_follows.user_id = $1) AS follows,
+    (SELECT count(*) FROM posts
+        INNER JOIN feeds ON feeds.id = posts.feed_id
+        WHERE feeds.user_id = $1) AS posts
+`
+
+func (q *Queries) CountUserData(ctx context.Context, userID uuid.UUID) (database.CountUserDataRow, error) {
+	row := q.db.QueryRowContext(ctx, countUserData, userID)
+	var i database.CountUserDataRow
+	err := row.Scan(&i.Feeds, &i.Follows, &i.Posts)
+	return i, err
+}
+
+const deleteUser = `-- name: DeleteUser :execrows
+DELETE FROM users WHERE id = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getPostIDsOlderThan = `-- name: GetPostIDsOlderThan :many
+SELECT posts.id FROM posts
+WHERE COALESCE(posts.published_at, posts.created_at) < $1
+  AND ($2 IS NULL OR posts.feed_id = $2)
+  AND NOT EXISTS (
+    SELECT 1 FROM post_states
+    WHERE post_states.post_id = posts.id
+      AND post_states.saved_at IS NOT NULL
+  )
+ORDER BY posts.id
+`
+
+func (q *Queries) GetPostIDsOlderThan(ctx context.Context, arg database.GetPostIDsOlderThanParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getPostIDsOlderThan, arg.Cutoff, arg.FeedID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,68 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/eefret/gator/internal/database"
+	"github.com/lib/pq"
+)
+
+const getDigestPostsForUser = `-- name: GetDigestPostsForUser :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.content, posts.author, posts.categories, COALESCE(feed_follows.display_name, feeds.name) AS feed_name, post_states.read_at FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_states ON post_states.post_id = posts.id
+  AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1
+  AND (
+    posts.created_at > $2
+    OR (posts.created_at = $2 AND posts.id > $3)
+  )
+  AND posts.created_at <= $4
+  AND post_states.read_at IS NULL
+ORDER BY posts.created_at ASC, posts.id ASC
+LIMIT $5
+`
+
+func (q *Queries) GetDigestPostsForUser(ctx context.Context, arg database.GetDigestPostsForUserParams) ([]database.GetDigestPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDigestPostsForUser,
+		arg.UserID,
+		arg.Since,
+		arg.AfterID,
+		arg.Until,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []database.GetDigestPostsForUserRow
+	for rows.Next() {
+		var i database.GetDigestPostsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Content,
+			&i.Author,
+			pq.Array(&i.Categories),
+			&i.FeedName,
+			&i.ReadAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
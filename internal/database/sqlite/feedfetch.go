@@ -0,0 +1,149 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/eefret/gator/internal/database"
+)
+
+const createFeedFetch = `-- name: CreateFeedFetch :one
+INSERT INTO feed_fetches (feed_id, started_at, finished_at, http_status, bytes, items, new_posts, error)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+)
+RETURNING id, feed_id, started_at, finished_at, http_status, bytes, items, new_posts, error
+`
+
+func (q *Queries) CreateFeedFetch(ctx context.Context, arg database.CreateFeedFetchParams) (database.FeedFetch, error) {
+	row := q.db.QueryRowContext(ctx, createFeedFetch,
+		arg.FeedID,
+		arg.StartedAt,
+		arg.FinishedAt,
+		arg.HttpStatus,
+		arg.Bytes,
+		arg.Items,
+		arg.NewPosts,
+		arg.Error,
+	)
+	var i database.FeedFetch
+	err := row.Scan(
+		&i.ID,
+		&i.FeedID,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.HttpStatus,
+		&i.Bytes,
+		&i.Items,
+		&i.NewPosts,
+		&i.Error,
+	)
+	return i, err
+}
+
+const deleteFeedFetchesBefore = `-- name: DeleteFeedFetchesBefore :execrows
+DELETE FROM feed_fetches
+WHERE started_at < $1
+`
+
+func (q *Queries) DeleteFeedFetchesBefore(ctx context.Context, startedAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFeedFetchesBefore, startedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getFeedFetches = `-- name: GetFeedFetches :many
+SELECT feed_fetches.id, feed_fetches.feed_id, feed_fetches.started_at, feed_fetches.finished_at, feed_fetches.http_status, feed_fetches.bytes, feed_fetches.items, feed_fetches.new_posts, feed_fetches.error, feeds.name AS feed_name, feeds.url AS feed_url
+FROM feed_fetches
+INNER JOIN feeds ON feeds.id = feed_fetches.feed_id
+ORDER BY feed_fetches.started_at DESC
+LIMIT $1
+`
+
+func (q *Queries) GetFeedFetches(ctx context.Context, limit int32) ([]database.GetFeedFetchesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedFetches, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []database.GetFeedFetchesRow
+	for rows.Next() {
+		var i database.GetFeedFetchesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FeedID,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.HttpStatus,
+			&i.Bytes,
+			&i.Items,
+			&i.NewPosts,
+			&i.Error,
+			&i.FeedName,
+			&i.FeedUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedFetchesForFeed = `-- name: GetFeedFetchesForFeed :many
+SELECT feed_fetches.id, feed_fetches.feed_id, feed_fetches.started_at, feed_fetches.finished_at, feed_fetches.http_status, feed_fetches.bytes, feed_fetches.items, feed_fetches.new_posts, feed_fetches.error, feeds.name AS feed_name, feeds.url AS feed_url
+FROM feed_fetches
+INNER JOIN feeds ON feeds.id = feed_fetches.feed_id
+WHERE feeds.url = $1
+ORDER BY feed_fetches.started_at DESC
+LIMIT $2
+`
+
+func (q *Queries) GetFeedFetchesForFeed(ctx context.Context, arg database.GetFeedFetchesForFeedParams) ([]database.GetFeedFetchesForFeedRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedFetchesForFeed, arg.Url, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []database.GetFeedFetchesForFeedRow
+	for rows.Next() {
+		var i database.GetFeedFetchesForFeedRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FeedID,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.HttpStatus,
+			&i.Bytes,
+			&i.Items,
+			&i.NewPosts,
+			&i.Error,
+			&i.FeedName,
+			&i.FeedUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,100 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/eefret/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+const createFeedFollow = `-- name: CreateFeedFollow :one
+INSERT INTO feed_follows (feed_id, user_id)
+VALUES ($1, $2)
+RETURNING id, created_at, updated_at, feed_id, user_id, display_name,
+    (SELECT feeds.name FROM feeds WHERE feeds.id = feed_id) AS feed_name,
+    (SELECT users.name FROM users WHERE users.id = user_id) AS user_name
+`
+
+func (q *Queries) CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) (database.CreateFeedFollowRow, error) {
+	row := q.db.QueryRowContext(ctx, createFeedFollow, arg.FeedID, arg.UserID)
+	var i database.CreateFeedFollowRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FeedID,
+		&i.UserID,
+		&i.DisplayName,
+		&i.FeedName,
+		&i.UserName,
+	)
+	return i, err
+}
+
+const deleteFeedFollow = `-- name: DeleteFeedFollow :execrows
+DELETE FROM feed_follows
+WHERE user_id = $1
+  AND feed_id IN (SELECT id FROM feeds WHERE url = $2)
+`
+
+func (q *Queries) DeleteFeedFollow(ctx context.Context, arg database.DeleteFeedFollowParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFeedFollow, arg.UserID, arg.Url)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
+SELECT ff.id, ff.user_id, ff.feed_id, ff.created_at, ff.updated_at,
+	       COALESCE(ff.display_name, f.name) AS feed_name,
+	       u.name AS user_name,
+	       f.url AS feed_url
+	FROM feed_follows ff
+	INNER JOIN feeds f ON f.id = ff.feed_id
+	INNER JOIN users u ON u.id = ff.user_id
+	WHERE ff.user_id = $1
+`
+
+func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetFeedFollowsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedFollowsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []database.GetFeedFollowsForUserRow
+	for rows.Next() {
+		var i database.GetFeedFollowsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.FeedID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.FeedName,
+			&i.UserName,
+			&i.FeedUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setFeedFollowDisplayName = `-- name: SetFeedFollowDisplayName :exec
+UPDATE feed_follows
+SET display_name = $2, updated_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) SetFeedFollowDisplayName(ctx context.Context, arg database.SetFeedFollowDisplayNameParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFollowDisplayName, arg.ID, arg.DisplayName)
+	return err
+}
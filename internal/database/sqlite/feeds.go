@@ -0,0 +1,262 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/eefret/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+const createFeed = `-- name: CreateFeed :one
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, fetch_full_content, retention_days, retention_posts, fetch_interval_seconds, enabled
+`
+
+func (q *Queries) CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error) {
+	row := q.db.QueryRowContext(ctx, createFeed,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Name,
+		arg.Url,
+		arg.UserID,
+	)
+	var i database.Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.FetchFullContent,
+		&i.RetentionDays,
+		&i.RetentionPosts,
+		&i.FetchIntervalSeconds,
+		&i.Enabled,
+	)
+	return i, err
+}
+
+const getFeedByURL = `-- name: GetFeedByURL :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, fetch_full_content, retention_days, retention_posts, fetch_interval_seconds, enabled FROM feeds WHERE url = $1
+`
+
+func (q *Queries) GetFeedByURL(ctx context.Context, url string) (database.Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByURL, url)
+	var i database.Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.FetchFullContent,
+		&i.RetentionDays,
+		&i.RetentionPosts,
+		&i.FetchIntervalSeconds,
+		&i.Enabled,
+	)
+	return i, err
+}
+
+const getFeeds = `-- name: GetFeeds :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, fetch_full_content, retention_days, retention_posts, fetch_interval_seconds, enabled FROM feeds
+`
+
+func (q *Queries) GetFeeds(ctx context.Context) ([]database.Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeeds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []database.Feed
+	for rows.Next() {
+		var i database.Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.FetchFullContent,
+			&i.RetentionDays,
+			&i.RetentionPosts,
+			&i.FetchIntervalSeconds,
+			&i.Enabled,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedsWithUsers = `-- name: GetFeedsWithUsers :many
+SELECT feeds.id, feeds.created_at, feeds.updated_at, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.fetch_full_content, feeds.retention_days, feeds.retention_posts, feeds.fetch_interval_seconds, feeds.enabled, users.name AS user_name FROM feeds
+INNER JOIN users ON users.id = feeds.user_id
+ORDER BY feeds.created_at ASC
+`
+
+func (q *Queries) GetFeedsWithUsers(ctx context.Context) ([]database.GetFeedsWithUsersRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsWithUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []database.GetFeedsWithUsersRow
+	for rows.Next() {
+		var i database.GetFeedsWithUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.FetchFullContent,
+			&i.RetentionDays,
+			&i.RetentionPosts,
+			&i.FetchIntervalSeconds,
+			&i.Enabled,
+			&i.UserName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, fetch_full_content, retention_days, retention_posts, fetch_interval_seconds, enabled FROM feeds
+WHERE enabled
+  AND (
+    fetch_interval_seconds IS NULL
+    OR last_fetched_at IS NULL
+    OR unixepoch(last_fetched_at) + fetch_interval_seconds <= unixepoch(now())
+  )
+ORDER BY last_fetched_at ASC NULLS FIRST
+LIMIT 1
+`
+
+// Feeds that are disabled or fetched more recently than their own interval
+// are skipped.
+func (q *Queries) GetNextFeedToFetch(ctx context.Context) (database.Feed, error) {
+	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
+	var i database.Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.FetchFullContent,
+		&i.RetentionDays,
+		&i.RetentionPosts,
+		&i.FetchIntervalSeconds,
+		&i.Enabled,
+	)
+	return i, err
+}
+
+const markFeedFetched = `-- name: MarkFeedFetched :exec
+UPDATE feeds
+SET last_fetched_at = now(), updated_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, markFeedFetched, id)
+	return err
+}
+
+const setFeedFetchFullContent = `-- name: SetFeedFetchFullContent :exec
+UPDATE feeds
+SET fetch_full_content = $2, updated_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) SetFeedFetchFullContent(ctx context.Context, arg database.SetFeedFetchFullContentParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFetchFullContent, arg.ID, arg.FetchFullContent)
+	return err
+}
+
+const setFeedRetention = `-- name: SetFeedRetention :exec
+UPDATE feeds
+SET retention_days = $2, retention_posts = $3, updated_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) SetFeedRetention(ctx context.Context, arg database.SetFeedRetentionParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedRetention, arg.ID, arg.RetentionDays, arg.RetentionPosts)
+	return err
+}
+
+const updateFeed = `-- name: UpdateFeed :one
+UPDATE feeds
+SET name = COALESCE($1, name),
+    url = COALESCE($2, url),
+    fetch_interval_seconds = CASE WHEN $3 THEN $4 ELSE fetch_interval_seconds END,
+    enabled = COALESCE($5, enabled),
+    updated_at = now()
+WHERE id = $6
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, fetch_full_content, retention_days, retention_posts, fetch_interval_seconds, enabled
+`
+
+// Fields left NULL keep their value. The interval is only changed when
+// set_interval is true, so it can also be cleared.
+func (q *Queries) UpdateFeed(ctx context.Context, arg database.UpdateFeedParams) (database.Feed, error) {
+	row := q.db.QueryRowContext(ctx, updateFeed,
+		arg.Name,
+		arg.Url,
+		arg.SetInterval,
+		arg.FetchIntervalSeconds,
+		arg.Enabled,
+		arg.ID,
+	)
+	var i database.Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.FetchFullContent,
+		&i.RetentionDays,
+		&i.RetentionPosts,
+		&i.FetchIntervalSeconds,
+		&i.Enabled,
+	)
+	return i, err
+}
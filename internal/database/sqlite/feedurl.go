@@ -0,0 +1,157 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/eefret/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+const createFeedURLChange = `-- name: CreateFeedURLChange :one
+INSERT INTO feed_url_history (feed_id, old_url, new_url, reason)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING id, created_at, feed_id, old_url, new_url, reason
+`
+
+func (q *Queries) CreateFeedURLChange(ctx context.Context, arg database.CreateFeedURLChangeParams) (database.FeedUrlHistory, error) {
+	row := q.db.QueryRowContext(ctx, createFeedURLChange,
+		arg.FeedID,
+		arg.OldUrl,
+		arg.NewUrl,
+		arg.Reason,
+	)
+	var i database.FeedUrlHistory
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.FeedID,
+		&i.OldUrl,
+		&i.NewUrl,
+		&i.Reason,
+	)
+	return i, err
+}
+
+const deleteFeed = `-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteFeed, id)
+	return err
+}
+
+const getFeedURLHistory = `-- name: GetFeedURLHistory :many
+SELECT id, created_at, feed_id, old_url, new_url, reason FROM feed_url_history
+WHERE feed_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) GetFeedURLHistory(ctx context.Context, feedID uuid.UUID) ([]database.FeedUrlHistory, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedURLHistory, feedID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []database.FeedUrlHistory
+	for rows.Next() {
+		var i database.FeedUrlHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.FeedID,
+			&i.OldUrl,
+			&i.NewUrl,
+			&i.Reason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const moveFeedFetches = `-- name: MoveFeedFetches :exec
+UPDATE feed_fetches
+SET feed_id = $1
+WHERE feed_id = $2
+`
+
+func (q *Queries) MoveFeedFetches(ctx context.Context, arg database.MoveFeedFetchesParams) error {
+	_, err := q.db.ExecContext(ctx, moveFeedFetches, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const moveFeedFollows = `-- name: MoveFeedFollows :exec
+INSERT INTO feed_follows (feed_id, user_id, created_at, updated_at, display_name)
+SELECT $1, user_id, created_at, now(), display_name
+FROM feed_follows
+WHERE feed_follows.feed_id = $2
+ON CONFLICT (feed_id, user_id) DO NOTHING
+`
+
+func (q *Queries) MoveFeedFollows(ctx context.Context, arg database.MoveFeedFollowsParams) error {
+	_, err := q.db.ExecContext(ctx, moveFeedFollows, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const moveFeedURLHistory = `-- name: MoveFeedURLHistory :exec
+UPDATE feed_url_history
+SET feed_id = $1
+WHERE feed_id = $2
+`
+
+func (q *Queries) MoveFeedURLHistory(ctx context.Context, arg database.MoveFeedURLHistoryParams) error {
+	_, err := q.db.ExecContext(ctx, moveFeedURLHistory, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const moveFollowTags = `-- name: MoveFollowTags :exec
+INSERT INTO follow_tags (feed_follow_id, tag, created_at)
+SELECT target.id, ft.tag, ft.created_at
+FROM follow_tags ft
+INNER JOIN feed_follows source ON source.id = ft.feed_follow_id
+INNER JOIN feed_follows target ON target.user_id = source.user_id
+  AND target.feed_id = $1
+WHERE source.feed_id = $2
+ON CONFLICT DO NOTHING
+`
+
+func (q *Queries) MoveFollowTags(ctx context.Context, arg database.MoveFollowTagsParams) error {
+	_, err := q.db.ExecContext(ctx, moveFollowTags, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const movePosts = `-- name: MovePosts :exec
+UPDATE posts
+SET feed_id = $1, updated_at = now()
+WHERE feed_id = $2
+`
+
+func (q *Queries) MovePosts(ctx context.Context, arg database.MovePostsParams) error {
+	_, err := q.db.ExecContext(ctx, movePosts, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const updateFeedURL = `-- name: UpdateFeedURL :exec
+UPDATE feeds
+SET url = $2, updated_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) UpdateFeedURL(ctx context.Context, arg database.UpdateFeedURLParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedURL, arg.ID, arg.Url)
+	return err
+}
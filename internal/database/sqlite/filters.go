@@ -0,0 +1,104 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/eefret/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+const createFilterRule = `-- name: CreateFilterRule :one
+INSERT INTO filter_rules (user_id, field, pattern, is_regex, action, feed_id, tag)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, created_at, user_id, field, pattern, is_regex, action, feed_id, tag
+`
+
+func (q *Queries) CreateFilterRule(ctx context.Context, arg database.CreateFilterRuleParams) (database.FilterRule, error) {
+	row := q.db.QueryRowContext(ctx, createFilterRule,
+		arg.UserID,
+		arg.Field,
+		arg.Pattern,
+		arg.IsRegex,
+		arg.Action,
+		arg.FeedID,
+		arg.Tag,
+	)
+	var i database.FilterRule
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UserID,
+		&i.Field,
+		&i.Pattern,
+		&i.IsRegex,
+		&i.Action,
+		&i.FeedID,
+		&i.Tag,
+	)
+	return i, err
+}
+
+const deleteFilterRule = `-- name: DeleteFilterRule :execrows
+DELETE FROM filter_rules
+WHERE user_id = $1 AND id = $2
+`
+
+func (q *Queries) DeleteFilterRule(ctx context.Context, arg database.DeleteFilterRuleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFilterRule, arg.UserID, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getFilterRulesForUser = `-- name: GetFilterRulesForUser :many
+SELECT filter_rules.id, filter_rules.created_at, filter_rules.user_id, filter_rules.field, filter_rules.pattern, filter_rules.is_regex, filter_rules.action, filter_rules.feed_id, filter_rules.tag, feeds.url AS feed_url FROM filter_rules
+LEFT JOIN feeds ON feeds.id = filter_rules.feed_id
+WHERE filter_rules.user_id = $1
+ORDER BY filter_rules.created_at ASC
+`
+
+func (q *Queries) GetFilterRulesForUser(ctx context.Context, userID uuid.UUID) ([]database.GetFilterRulesForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFilterRulesForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []database.GetFilterRulesForUserRow
+	for rows.Next() {
+		var i database.GetFilterRulesForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UserID,
+			&i.Field,
+			&i.Pattern,
+			&i.IsRegex,
+			&i.Action,
+			&i.FeedID,
+			&i.Tag,
+			&i.FeedUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const moveFilterRules = `-- name: MoveFilterRules :exec
+UPDATE filter_rules
+SET feed_id = $1
+WHERE feed_id = $2
+`
+
+func (q *Queries) MoveFilterRules(ctx context.Context, arg database.MoveFilterRulesParams) error {
+	_, err := q.db.ExecContext(ctx, moveFilterRules, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
@@ -0,0 +1,82 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/eefret/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+const createFollowTag = `-- name: CreateFollowTag :exec
+INSERT INTO follow_tags (feed_follow_id, tag)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+func (q *Queries) CreateFollowTag(ctx context.Context, arg database.CreateFollowTagParams) error {
+	_, err := q.db.ExecContext(ctx, createFollowTag, arg.FeedFollowID, arg.Tag)
+	return err
+}
+
+const deleteFollowTag = `-- name: DeleteFollowTag :execrows
+DELETE FROM follow_tags
+WHERE feed_follow_id = $1 AND tag = $2
+`
+
+func (q *Queries) DeleteFollowTag(ctx context.Context, arg database.DeleteFollowTagParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFollowTag, arg.FeedFollowID, arg.Tag)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getFeedFollowForUser = `-- name: GetFeedFollowForUser :one
+SELECT ff.id, ff.created_at, ff.updated_at, ff.feed_id, ff.user_id, ff.display_name FROM feed_follows ff
+INNER JOIN feeds f ON f.id = ff.feed_id
+WHERE ff.user_id = $1 AND f.url = $2
+`
+
+func (q *Queries) GetFeedFollowForUser(ctx context.Context, arg database.GetFeedFollowForUserParams) (database.FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedFollowForUser, arg.UserID, arg.Url)
+	var i database.FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FeedID,
+		&i.UserID,
+		&i.DisplayName,
+	)
+	return i, err
+}
+
+const getFollowTagsForUser = `-- name: GetFollowTagsForUser :many
+SELECT ff.feed_id, ft.tag FROM follow_tags ft
+INNER JOIN feed_follows ff ON ff.id = ft.feed_follow_id
+WHERE ff.user_id = $1
+ORDER BY ft.tag
+`
+
+func (q *Queries) GetFollowTagsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetFollowTagsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowTagsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []database.GetFollowTagsForUserRow
+	for rows.Next() {
+		var i database.GetFollowTagsForUserRow
+		if err := rows.Scan(&i.FeedID, &i.Tag); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
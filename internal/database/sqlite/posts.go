@@ -0,0 +1,165 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/eefret/gator/internal/database"
+	"github.com/lib/pq"
+)
+
+const createPost = `-- name: CreatePost :one
+INSERT INTO posts (title, url, description, published_at, feed_id, author, categories)
+SELECT
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+WHERE NOT EXISTS (
+    SELECT 1 FROM pruned_posts WHERE pruned_posts.url = $2
+)
+ON CONFLICT (url) DO NOTHING
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, content, author, categories
+`
+
+func (q *Queries) CreatePost(ctx context.Context, arg database.CreatePostParams) (database.Post, error) {
+	row := q.db.QueryRowContext(ctx, createPost,
+		arg.Title,
+		arg.Url,
+		arg.Description,
+		arg.PublishedAt,
+		arg.FeedID,
+		arg.Author,
+		pq.Array(arg.Categories),
+	)
+	var i database.Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.Content,
+		&i.Author,
+		pq.Array(&i.Categories),
+	)
+	return i, err
+}
+
+const getPostsForUser = `-- name: GetPostsForUser :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.content, posts.author, posts.categories, COALESCE(feed_follows.display_name, feeds.name) AS feed_name, post_states.read_at FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_states ON post_states.post_id = posts.id
+  AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1
+ORDER BY posts.published_at DESC NULLS FIRST, posts.id ASC
+LIMIT $2 OFFSET $3
+`
+
+func (q *Queries) GetPostsForUser(ctx context.Context, arg database.GetPostsForUserParams) ([]database.GetPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []database.GetPostsForUserRow
+	for rows.Next() {
+		var i database.GetPostsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Content,
+			&i.Author,
+			pq.Array(&i.Categories),
+			&i.FeedName,
+			&i.ReadAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsForUserByTag = `-- name: GetPostsForUserByTag :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.content, posts.author, posts.categories, COALESCE(feed_follows.display_name, feeds.name) AS feed_name, post_states.read_at FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN follow_tags ON follow_tags.feed_follow_id = feed_follows.id
+JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_states ON post_states.post_id = posts.id
+  AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND follow_tags.tag = $2
+ORDER BY posts.published_at DESC NULLS FIRST, posts.id ASC
+LIMIT $3 OFFSET $4
+`
+
+func (q *Queries) GetPostsForUserByTag(ctx context.Context, arg database.GetPostsForUserByTagParams) ([]database.GetPostsForUserByTagRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUserByTag,
+		arg.UserID,
+		arg.Tag,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []database.GetPostsForUserByTagRow
+	for rows.Next() {
+		var i database.GetPostsForUserByTagRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Content,
+			&i.Author,
+			pq.Array(&i.Categories),
+			&i.FeedName,
+			&i.ReadAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setPostContent = `-- name: SetPostContent :exec
+UPDATE posts
+SET content = $2, updated_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) SetPostContent(ctx context.Context, arg database.SetPostContentParams) error {
+	_, err := q.db.ExecContext(ctx, setPostContent, arg.ID, arg.Content)
+	return err
+}
@@ -0,0 +1,31 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/eefret/gator/internal/database"
+)
+
+const markPostRead = `-- name: MarkPostRead :exec
+INSERT INTO post_states (user_id, post_id, read_at)
+VALUES ($1, $2, now())
+ON CONFLICT (user_id, post_id)
+DO UPDATE SET read_at = COALESCE(post_states.read_at, excluded.read_at)
+`
+
+func (q *Queries) MarkPostRead(ctx context.Context, arg database.MarkPostReadParams) error {
+	_, err := q.db.ExecContext(ctx, markPostRead, arg.UserID, arg.PostID)
+	return err
+}
+
+const savePost = `-- name: SavePost :exec
+INSERT INTO post_states (user_id, post_id, saved_at)
+VALUES ($1, $2, now())
+ON CONFLICT (user_id, post_id)
+DO UPDATE SET saved_at = COALESCE(post_states.saved_at, excluded.saved_at)
+`
+
+func (q *Queries) SavePost(ctx context.Context, arg database.SavePostParams) error {
+	_, err := q.db.ExecContext(ctx, savePost, arg.UserID, arg.PostID)
+	return err
+}
@@ -0,0 +1,124 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/eefret/gator/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createPrunedPosts = `-- name: CreatePrunedPosts :exec
+INSERT INTO pruned_posts (url, feed_id)
+SELECT url, feed_id FROM posts
+WHERE id IN (SELECT value FROM json_each($1))
+ON CONFLICT (url) DO NOTHING
+`
+
+func (q *Queries) CreatePrunedPosts(ctx context.Context, ids []uuid.UUID) error {
+	// SQLite has no arrays; the IDs are passed as a JSON array.
+	idList, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	_, err = q.db.ExecContext(ctx, createPrunedPosts, string(idList))
+	return err
+}
+
+const deletePostsByID = `-- name: DeletePostsByID :execrows
+DELETE FROM posts WHERE id IN (SELECT value FROM json_each($1))
+`
+
+func (q *Queries) DeletePostsByID(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	// SQLite has no arrays; the IDs are passed as a JSON array.
+	idList, err := json.Marshal(ids)
+	if err != nil {
+		return 0, err
+	}
+	result, err := q.db.ExecContext(ctx, deletePostsByID, string(idList))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deletePrunedPostsBefore = `-- name: DeletePrunedPostsBefore :execrows
+DELETE FROM pruned_posts
+WHERE feed_id = $1 AND pruned_at < $2
+`
+
+// Pruned URLs are forgotten once the feed is unlikely to list them again.
+func (q *Queries) DeletePrunedPostsBefore(ctx context.Context, arg database.DeletePrunedPostsBeforeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePrunedPostsBefore, arg.FeedID, arg.Cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getPrunablePosts = `-- name: GetPrunablePosts :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.content, posts.author, posts.categories FROM posts
+WHERE posts.feed_id = $1
+  AND (
+    COALESCE(posts.published_at, posts.created_at) < $2
+    OR posts.id NOT IN (
+      SELECT newest.id FROM posts AS newest
+      WHERE newest.feed_id = $1
+      ORDER BY COALESCE(newest.published_at, newest.created_at) DESC
+      LIMIT COALESCE($3, -1)
+    )
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM post_states
+    WHERE post_states.post_id = posts.id
+      AND post_states.saved_at IS NOT NULL
+  )
+ORDER BY COALESCE(posts.published_at, posts.created_at) ASC
+`
+
+func (q *Queries) GetPrunablePosts(ctx context.Context, arg database.GetPrunablePostsParams) ([]database.Post, error) {
+	rows, err := q.db.QueryContext(ctx, getPrunablePosts, arg.FeedID, arg.Cutoff, arg.Keep)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []database.Post
+	for rows.Next() {
+		var i database.Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Content,
+			&i.Author,
+			pq.Array(&i.Categories),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const movePrunedPosts = `-- name: MovePrunedPosts :exec
+UPDATE pruned_posts
+SET feed_id = $1
+WHERE feed_id = $2
+`
+
+func (q *Queries) MovePrunedPosts(ctx context.Context, arg database.MovePrunedPostsParams) error {
+	_, err := q.db.ExecContext(ctx, movePrunedPosts, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
@@ -0,0 +1,166 @@
+// Package sqlite implements database.Store on SQLite with a pure-Go driver,
+// for running gator without a PostgreSQL server. Its queries mirror the ones
+// sqlc generates from sql/queries and return the same types, and the schema
+// comes from the same migrations, translated by Dialect.
+//
+// Timestamps are stored as fixed-width UTC text so they compare and sort
+// correctly as strings, and arrays as PostgreSQL array literals.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/eefret/gator/internal/database"
+	"github.com/eefret/gator/internal/migrate"
+	"github.com/google/uuid"
+	"modernc.org/sqlite"
+)
+
+// timeFormat is how timestamps are stored.
+const timeFormat = "2006-01-02T15:04:05.000000Z"
+
+func init() {
+	// The migrations and queries use these PostgreSQL functions.
+	sqlite.MustRegisterScalarFunction("now", 0, func(*sqlite.FunctionContext, []driver.Value) (driver.Value, error) {
+		return formatTime(time.Now()), nil
+	})
+	sqlite.MustRegisterScalarFunction("gen_random_uuid", 0, func(*sqlite.FunctionContext, []driver.Value) (driver.Value, error) {
+		return uuid.NewString(), nil
+	})
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+// Dialect runs the PostgreSQL migrations on SQLite.
+var Dialect = migrate.Dialect{
+	VersionTable: `CREATE TABLE IF NOT EXISTS goose_db_version (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    version_id BIGINT NOT NULL,
+    is_applied BOOLEAN NOT NULL,
+    tstamp TIMESTAMP DEFAULT (now())
+)`,
+	Rewrite: rewriteSchema,
+}
+
+var (
+	// SQLite only accepts function calls as defaults in parentheses.
+	defaultCall = regexp.MustCompile(`DEFAULT (now|gen_random_uuid)\(\)`)
+	// The driver returns TIMESTAMP columns as time.Time.
+	timestamptz = regexp.MustCompile(`\bTIMESTAMPTZ\b`)
+)
+
+// rewriteSchema translates a PostgreSQL migration to SQLite.
+func rewriteSchema(sql string) string {
+	sql = defaultCall.ReplaceAllString(sql, "DEFAULT ($1())")
+	return timestamptz.ReplaceAllString(sql, "TIMESTAMP")
+}
+
+// Store is a database.Store backed by a SQLite file.
+type Store struct {
+	*Queries
+	db *sql.DB
+}
+
+var _ database.Store = (*Store)(nil)
+
+// Open opens the SQLite database at path, creating the file if needed.
+// Foreign keys are enforced so deletes cascade as they do in PostgreSQL, and
+// transactions take the write lock up front so concurrent writers wait for
+// each other instead of failing.
+func Open(path string) (*Store, error) {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	dsn := "file:" + path + sep + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{Queries: New(db), db: db}, nil
+}
+
+// DB returns the underlying connection pool.
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+// InTx runs fn in a transaction, committing when it returns nil and rolling
+// back otherwise.
+func (s *Store) InTx(ctx context.Context, fn func(q database.Querier) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(s.WithTx(tx)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Queries implements database.Querier on SQLite.
+type Queries struct {
+	db database.DBTX
+}
+
+var _ database.Querier = (*Queries)(nil)
+
+// New returns Queries running on db.
+func New(db database.DBTX) *Queries {
+	return &Queries{db: conn{db}}
+}
+
+// WithTx returns Queries running in tx.
+func (q *Queries) WithTx(tx *sql.Tx) *Queries {
+	return New(tx)
+}
+
+// conn stores time arguments in timeFormat; the driver's own format is
+// neither fixed-width nor normalized to UTC.
+type conn struct {
+	db database.DBTX
+}
+
+func (c conn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return c.db.ExecContext(ctx, query, convertArgs(args)...)
+}
+
+func (c conn) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return c.db.PrepareContext(ctx, query)
+}
+
+func (c conn) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return c.db.QueryContext(ctx, query, convertArgs(args)...)
+}
+
+func (c conn) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return c.db.QueryRowContext(ctx, query, convertArgs(args)...)
+}
+
+func convertArgs(args []interface{}) []interface{} {
+	converted := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case time.Time:
+			converted[i] = formatTime(v)
+		case sql.NullTime:
+			if v.Valid {
+				converted[i] = formatTime(v.Time)
+			}
+		default:
+			converted[i] = arg
+		}
+	}
+	return converted
+}
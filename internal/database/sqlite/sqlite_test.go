@@ -0,0 +1,66 @@
+package sqlite_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eefret/gator/internal/database"
+	"github.com/eefret/gator/internal/database/sqlite"
+	"github.com/eefret/gator/internal/database/storetest"
+	"github.com/eefret/gator/internal/migrate"
+)
+
+// openMigrated opens a new database file with every migration applied.
+func openMigrated(t *testing.T) (*sqlite.Store, *migrate.Migrator) {
+	t.Helper()
+	s, err := sqlite.Open(filepath.Join(t.TempDir(), "gator.db"))
+	if err != nil {
+		t.Fatalf("Expected Open to succeed, got %v", err)
+	}
+	t.Cleanup(func() { s.DB().Close() })
+
+	migrations, err := migrate.Load(os.DirFS("../../../sql/schema"))
+	if err != nil {
+		t.Fatalf("Expected sql/schema to load, got %v", err)
+	}
+	m := migrate.New(s.DB(), migrations, sqlite.Dialect)
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatalf("Expected the migrations to apply, got %v", err)
+	}
+	return s, m
+}
+
+// TestConformance runs the store suite against SQLite.
+func TestConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) database.Store {
+		s, _ := openMigrated(t)
+		return s
+	})
+}
+
+// TestMigrateDown checks that every migration rolls back and reapplies on
+// SQLite.
+func TestMigrateDown(t *testing.T) {
+	ctx := context.Background()
+	_, m := openMigrated(t)
+	latest, err := m.Version(ctx)
+	if err != nil {
+		t.Fatalf("Expected Version to succeed, got %v", err)
+	}
+
+	for v := latest; v > 0; v-- {
+		if _, err := m.Down(ctx); err != nil {
+			t.Fatalf("Expected migration %d to roll back, got %v", v, err)
+		}
+	}
+	if v, err := m.Version(ctx); err != nil || v != 0 {
+		t.Errorf("Expected version 0, got %d %v", v, err)
+	}
+
+	applied, err := m.Up(ctx)
+	if err != nil || int64(len(applied)) != latest {
+		t.Errorf("Expected %d migrations to reapply, got %d %v", latest, len(applied), err)
+	}
+}
@@ -0,0 +1,169 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/eefret/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+const countAdmins = `-- name: CountAdmins :one
+SELECT count(*) FROM users WHERE role = 'admin'
+`
+
+func (q *Queries) CountAdmins(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAdmins)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createUser = `-- name: CreateUser :one
+INSERT INTO users (id, created_at, updated_at, name, role)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    CASE WHEN EXISTS (SELECT 1 FROM users WHERE role = 'admin') THEN 'member' ELSE 'admin' END
+)
+RETURNING id, created_at, updated_at, name, email, last_digest_at, role
+`
+
+// The first user becomes an admin so a fresh install can be managed.
+func (q *Queries) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	row := q.db.QueryRowContext(ctx, createUser,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Name,
+	)
+	var i database.User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Email,
+		&i.LastDigestAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const getUser = `-- name: GetUser :one
+SELECT id, created_at, updated_at, name, email, last_digest_at, role FROM users 
+WHERE name = $1
+`
+
+func (q *Queries) GetUser(ctx context.Context, name string) (database.User, error) {
+	row := q.db.QueryRowContext(ctx, getUser, name)
+	var i database.User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Email,
+		&i.LastDigestAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const getUserById = `-- name: GetUserById :one
+SELECT id, created_at, updated_at, name, email, last_digest_at, role FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (database.User, error) {
+	row := q.db.QueryRowContext(ctx, getUserById, id)
+	var i database.User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Email,
+		&i.LastDigestAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const getUsers = `-- name: GetUsers :many
+SELECT id, created_at, updated_at, name, email, last_digest_at, role FROM users
+`
+
+func (q *Queries) GetUsers(ctx context.Context) ([]database.User, error) {
+	rows, err := q.db.QueryContext(ctx, getUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []database.User
+	for rows.Next() {
+		var i database.User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Email,
+			&i.LastDigestAt,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const resetUsers = `-- name: ResetUsers :exec
+DELETE FROM users
+`
+
+func (q *Queries) ResetUsers(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, resetUsers)
+	return err
+}
+
+const setUserEmail = `-- name: SetUserEmail :exec
+UPDATE users
+SET email = $2, updated_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) SetUserEmail(ctx context.Context, arg database.SetUserEmailParams) error {
+	_, err := q.db.ExecContext(ctx, setUserEmail, arg.ID, arg.Email)
+	return err
+}
+
+const setUserLastDigestAt = `-- name: SetUserLastDigestAt :exec
+UPDATE users
+SET last_digest_at = $2
+WHERE id = $1
+`
+
+func (q *Queries) SetUserLastDigestAt(ctx context.Context, arg database.SetUserLastDigestAtParams) error {
+	_, err := q.db.ExecContext(ctx, setUserLastDigestAt, arg.ID, arg.LastDigestAt)
+	return err
+}
+
+const setUserRole = `-- name: SetUserRole :exec
+UPDATE users
+SET role = $2, updated_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) SetUserRole(ctx context.Context, arg database.SetUserRoleParams) error {
+	_, err := q.db.ExecContext(ctx, setUserRole, arg.ID, arg.Role)
+	return err
+}
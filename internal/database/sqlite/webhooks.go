@@ -0,0 +1,209 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/eefret/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+const createWebhook = `-- name: CreateWebhook :one
+INSERT INTO webhooks (user_id, url, secret, feed_id, tag, keywords, payload_template)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, created_at, user_id, url, secret, feed_id, tag, keywords, payload_template
+`
+
+func (q *Queries) CreateWebhook(ctx context.Context, arg database.CreateWebhookParams) (database.Webhook, error) {
+	row := q.db.QueryRowContext(ctx, createWebhook,
+		arg.UserID,
+		arg.Url,
+		arg.Secret,
+		arg.FeedID,
+		arg.Tag,
+		arg.Keywords,
+		arg.PayloadTemplate,
+	)
+	var i database.Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UserID,
+		&i.Url,
+		&i.Secret,
+		&i.FeedID,
+		&i.Tag,
+		&i.Keywords,
+		&i.PayloadTemplate,
+	)
+	return i, err
+}
+
+const createWebhookDelivery = `-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (webhook_id, post_id, attempts, status_code, error)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+func (q *Queries) CreateWebhookDelivery(ctx context.Context, arg database.CreateWebhookDeliveryParams) error {
+	_, err := q.db.ExecContext(ctx, createWebhookDelivery,
+		arg.WebhookID,
+		arg.PostID,
+		arg.Attempts,
+		arg.StatusCode,
+		arg.Error,
+	)
+	return err
+}
+
+const deleteWebhook = `-- name: DeleteWebhook :execrows
+DELETE FROM webhooks
+WHERE user_id = $1 AND id = $2
+`
+
+func (q *Queries) DeleteWebhook(ctx context.Context, arg database.DeleteWebhookParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteWebhook, arg.UserID, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getWebhookDeliveriesForUser = `-- name: GetWebhookDeliveriesForUser :many
+SELECT webhook_deliveries.id, webhook_deliveries.created_at, webhook_deliveries.webhook_id, webhook_deliveries.post_id, webhook_deliveries.attempts, webhook_deliveries.status_code, webhook_deliveries.error, webhooks.url AS webhook_url, posts.title AS post_title
+FROM webhook_deliveries
+INNER JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id
+LEFT JOIN posts ON posts.id = webhook_deliveries.post_id
+WHERE webhooks.user_id = $1
+ORDER BY webhook_deliveries.created_at DESC
+LIMIT $2
+`
+
+func (q *Queries) GetWebhookDeliveriesForUser(ctx context.Context, arg database.GetWebhookDeliveriesForUserParams) ([]database.GetWebhookDeliveriesForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getWebhookDeliveriesForUser, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []database.GetWebhookDeliveriesForUserRow
+	for rows.Next() {
+		var i database.GetWebhookDeliveriesForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.WebhookID,
+			&i.PostID,
+			&i.Attempts,
+			&i.StatusCode,
+			&i.Error,
+			&i.WebhookUrl,
+			&i.PostTitle,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWebhooksForFeed = `-- name: GetWebhooksForFeed :many
+SELECT webhooks.id, webhooks.created_at, webhooks.user_id, webhooks.url, webhooks.secret, webhooks.feed_id, webhooks.tag, webhooks.keywords, webhooks.payload_template, feed_follows.display_name AS follow_display_name FROM webhooks
+INNER JOIN feed_follows ON feed_follows.user_id = webhooks.user_id
+  AND feed_follows.feed_id = $1
+WHERE (webhooks.feed_id IS NULL OR webhooks.feed_id = $1)
+  AND (webhooks.tag IS NULL OR EXISTS (
+    SELECT 1 FROM follow_tags
+    WHERE follow_tags.feed_follow_id = feed_follows.id
+      AND follow_tags.tag = webhooks.tag
+  ))
+`
+
+func (q *Queries) GetWebhooksForFeed(ctx context.Context, feedID uuid.UUID) ([]database.GetWebhooksForFeedRow, error) {
+	rows, err := q.db.QueryContext(ctx, getWebhooksForFeed, feedID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []database.GetWebhooksForFeedRow
+	for rows.Next() {
+		var i database.GetWebhooksForFeedRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UserID,
+			&i.Url,
+			&i.Secret,
+			&i.FeedID,
+			&i.Tag,
+			&i.Keywords,
+			&i.PayloadTemplate,
+			&i.FollowDisplayName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWebhooksForUser = `-- name: GetWebhooksForUser :many
+SELECT webhooks.id, webhooks.created_at, webhooks.user_id, webhooks.url, webhooks.secret, webhooks.feed_id, webhooks.tag, webhooks.keywords, webhooks.payload_template, feeds.url AS feed_url FROM webhooks
+LEFT JOIN feeds ON feeds.id = webhooks.feed_id
+WHERE webhooks.user_id = $1
+ORDER BY webhooks.created_at ASC
+`
+
+func (q *Queries) GetWebhooksForUser(ctx context.Context, userID uuid.UUID) ([]database.GetWebhooksForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getWebhooksForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []database.GetWebhooksForUserRow
+	for rows.Next() {
+		var i database.GetWebhooksForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UserID,
+			&i.Url,
+			&i.Secret,
+			&i.FeedID,
+			&i.Tag,
+			&i.Keywords,
+			&i.PayloadTemplate,
+			&i.FeedUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const moveWebhooks = `-- name: MoveWebhooks :exec
+UPDATE webhooks
+SET feed_id = $1
+WHERE feed_id = $2
+`
+
+func (q *Queries) MoveWebhooks(ctx context.Context, arg database.MoveWebhooksParams) error {
+	_, err := q.db.ExecContext(ctx, moveWebhooks, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// Store is the storage gator runs on: the queries plus the connection they
+// run on, so operations spanning several statements can run in a
+// transaction. PostgresStore implements it with the generated queries and
+// the sqlite package with SQLite.
+type Store interface {
+	Querier
+	// InTx runs fn in a transaction, committing when it returns nil and
+	// rolling back otherwise.
+	InTx(ctx context.Context, fn func(q Querier) error) error
+	// DB returns the underlying connection pool.
+	DB() *sql.DB
+}
+
+// PostgresStore is a Store backed by PostgreSQL.
+type PostgresStore struct {
+	*Queries
+	db *sql.DB
+}
+
+var _ Store = (*PostgresStore)(nil)
+
+// NewPostgresStore returns a Store running queries on db.
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{Queries: New(db), db: db}
+}
+
+// DB returns the underlying connection pool.
+func (s *PostgresStore) DB() *sql.DB {
+	return s.db
+}
+
+// InTx runs fn in a transaction, committing when it returns nil and rolling
+// back otherwise.
+func (s *PostgresStore) InTx(ctx context.Context, fn func(q Querier) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(s.WithTx(tx)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// IsUniqueViolation reports whether err is a unique constraint violation in
+// either backend.
+func IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505"
+	}
+	// SQLite driver errors are matched by their extended result code so
+	// this package does not depend on the driver.
+	var sqliteErr interface{ Code() int }
+	if errors.As(err, &sqliteErr) {
+		code := sqliteErr.Code()
+		return code == sqliteConstraintUnique || code == sqliteConstraintPrimaryKey
+	}
+	return false
+}
+
+// Extended SQLite result codes for constraint violations.
+const (
+	sqliteConstraintPrimaryKey = 1555
+	sqliteConstraintUnique     = 2067
+)
@@ -0,0 +1,46 @@
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/eefret/gator/internal/database"
+	"github.com/eefret/gator/internal/database/storetest"
+	"github.com/eefret/gator/internal/migrate"
+	_ "github.com/lib/pq"
+)
+
+// TestPostgresConformance runs the store suite against the PostgreSQL
+// database in GATOR_TEST_POSTGRES_URL. Its data is wiped before every test.
+func TestPostgresConformance(t *testing.T) {
+	dbURL := os.Getenv("GATOR_TEST_POSTGRES_URL")
+	if dbURL == "" {
+		t.Skip("GATOR_TEST_POSTGRES_URL is not set")
+	}
+
+	storetest.Run(t, func(t *testing.T) database.Store {
+		ctx := context.Background()
+		db, err := sql.Open("postgres", dbURL)
+		if err != nil {
+			t.Fatalf("Expected to open the database, got %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		migrations, err := migrate.Load(os.DirFS("../../sql/schema"))
+		if err != nil {
+			t.Fatalf("Expected sql/schema to load, got %v", err)
+		}
+		if _, err := migrate.New(db, migrations, migrate.Postgres).Up(ctx); err != nil {
+			t.Fatalf("Expected the migrations to apply, got %v", err)
+		}
+
+		s := database.NewPostgresStore(db)
+		// Everything else belongs to a user and is removed with them.
+		if err := s.ResetUsers(ctx); err != nil {
+			t.Fatalf("Expected to wipe the database, got %v", err)
+		}
+		return s
+	})
+}
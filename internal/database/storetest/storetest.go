@@ -0,0 +1,979 @@
+// Package storetest is a conformance suite for database.Store
+// implementations, so every storage backend behaves like the PostgreSQL one
+// the queries were written for.
+package storetest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/eefret/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+// Run runs the suite. open must return an empty store with every migration
+// applied; it is called once per test.
+func Run(t *testing.T, open func(t *testing.T) database.Store) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s database.Store)
+	}{
+		{"Users", testUsers},
+		{"Feeds", testFeeds},
+		{"NextFeedToFetch", testNextFeedToFetch},
+		{"Follows", testFollows},
+		{"Posts", testPosts},
+		{"Retention", testRetention},
+		{"Digest", testDigest},
+		{"DigestPaging", testDigestPaging},
+		{"FeedFetches", testFeedFetches},
+		{"Webhooks", testWebhooks},
+		{"Filters", testFilters},
+		{"Cascade", testCascade},
+		{"MergeFeed", testMergeFeed},
+		{"Transactions", testTransactions},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, open(t))
+		})
+	}
+}
+
+func createUser(t *testing.T, q database.Querier, name string) database.User {
+	t.Helper()
+	now := time.Now()
+	user, err := q.CreateUser(context.Background(), database.CreateUserParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Name:      name,
+	})
+	if err != nil {
+		t.Fatalf("Expected to create user %s, got %v", name, err)
+	}
+	return user
+}
+
+func createFeed(t *testing.T, q database.Querier, user database.User, url string) database.Feed {
+	t.Helper()
+	now := time.Now()
+	feed, err := q.CreateFeed(context.Background(), database.CreateFeedParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Name:      url,
+		Url:       url,
+		UserID:    user.ID,
+	})
+	if err != nil {
+		t.Fatalf("Expected to create feed %s, got %v", url, err)
+	}
+	return feed
+}
+
+func createPost(t *testing.T, q database.Querier, feed database.Feed, url string, published time.Time) database.Post {
+	t.Helper()
+	params := database.CreatePostParams{
+		Title:      url,
+		Url:        url,
+		FeedID:     feed.ID,
+		Categories: []string{},
+	}
+	if !published.IsZero() {
+		params.PublishedAt = sql.NullTime{Time: published, Valid: true}
+	}
+	post, err := q.CreatePost(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Expected to create post %s, got %v", url, err)
+	}
+	return post
+}
+
+func postURLs[T any](posts []T, url func(T) string) []string {
+	urls := make([]string, 0, len(posts))
+	for _, post := range posts {
+		urls = append(urls, url(post))
+	}
+	return urls
+}
+
+// testUsers checks that the first user becomes an admin and that timestamps
+// and unique names round-trip.
+func testUsers(t *testing.T, s database.Store) {
+	ctx := context.Background()
+	created := time.Date(2024, 3, 1, 12, 30, 15, 123456000, time.UTC)
+	alice, err := s.CreateUser(ctx, database.CreateUserParams{ID: uuid.New(), CreatedAt: created, UpdatedAt: created, Name: "alice"})
+	if err != nil {
+		t.Fatalf("Expected to create alice, got %v", err)
+	}
+	bob := createUser(t, s, "bob")
+
+	if alice.Role != "admin" || bob.Role != "member" {
+		t.Errorf("Expected admin and member, got %s and %s", alice.Role, bob.Role)
+	}
+	if !alice.CreatedAt.Equal(created) {
+		t.Errorf("Expected created_at %v, got %v", created, alice.CreatedAt)
+	}
+
+	got, err := s.GetUser(ctx, "alice")
+	if err != nil || got.ID != alice.ID {
+		t.Errorf("Expected GetUser to find alice, got %v %v", got.ID, err)
+	}
+	if _, err := s.GetUserById(ctx, uuid.New()); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows for an unknown user, got %v", err)
+	}
+
+	_, err = s.CreateUser(ctx, database.CreateUserParams{ID: uuid.New(), CreatedAt: created, UpdatedAt: created, Name: "alice"})
+	if !database.IsUniqueViolation(err) {
+		t.Errorf("Expected a unique violation for a duplicate name, got %v", err)
+	}
+
+	if err := s.SetUserRole(ctx, database.SetUserRoleParams{ID: bob.ID, Role: "admin"}); err != nil {
+		t.Fatalf("Expected SetUserRole to succeed, got %v", err)
+	}
+	if n, err := s.CountAdmins(ctx); err != nil || n != 2 {
+		t.Errorf("Expected 2 admins, got %d %v", n, err)
+	}
+
+	digest := sql.NullTime{Time: created.Add(time.Hour), Valid: true}
+	if err := s.SetUserLastDigestAt(ctx, database.SetUserLastDigestAtParams{ID: alice.ID, LastDigestAt: digest}); err != nil {
+		t.Fatalf("Expected SetUserLastDigestAt to succeed, got %v", err)
+	}
+	got, err = s.GetUserById(ctx, alice.ID)
+	if err != nil || !got.LastDigestAt.Valid || !got.LastDigestAt.Time.Equal(digest.Time) {
+		t.Errorf("Expected last_digest_at %v, got %v %v", digest.Time, got.LastDigestAt, err)
+	}
+
+	email := sql.NullString{String: "alice@example.com", Valid: true}
+	if err := s.SetUserEmail(ctx, database.SetUserEmailParams{ID: alice.ID, Email: email}); err != nil {
+		t.Fatalf("Expected SetUserEmail to succeed, got %v", err)
+	}
+	got, err = s.GetUserById(ctx, alice.ID)
+	if err != nil || got.Email != email {
+		t.Errorf("Expected email %s, got %v %v", email.String, got.Email, err)
+	}
+
+	users, err := s.GetUsers(ctx)
+	if err != nil || len(users) != 2 {
+		t.Errorf("Expected 2 users, got %d %v", len(users), err)
+	}
+
+	if err := s.ResetUsers(ctx); err != nil {
+		t.Fatalf("Expected ResetUsers to succeed, got %v", err)
+	}
+	users, err = s.GetUsers(ctx)
+	if err != nil || len(users) != 0 {
+		t.Errorf("Expected no users after a reset, got %d %v", len(users), err)
+	}
+}
+
+// testFeeds checks feed creation, unique URLs and partial updates.
+func testFeeds(t *testing.T, s database.Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	feed := createFeed(t, s, alice, "https://example.com/feed")
+
+	if !feed.Enabled || feed.FetchFullContent || feed.LastFetchedAt.Valid || feed.FetchIntervalSeconds.Valid {
+		t.Errorf("Expected column defaults on a new feed, got %+v", feed)
+	}
+
+	_, err := s.CreateFeed(ctx, database.CreateFeedParams{ID: uuid.New(), Name: "copy", Url: feed.Url, UserID: alice.ID})
+	if !database.IsUniqueViolation(err) {
+		t.Errorf("Expected a unique violation for a duplicate url, got %v", err)
+	}
+
+	updated, err := s.UpdateFeed(ctx, database.UpdateFeedParams{
+		ID:                   feed.ID,
+		Name:                 sql.NullString{String: "Example", Valid: true},
+		SetInterval:          true,
+		FetchIntervalSeconds: sql.NullInt32{Int32: 3600, Valid: true},
+		Enabled:              sql.NullBool{Bool: false, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("Expected UpdateFeed to succeed, got %v", err)
+	}
+	if updated.Name != "Example" || updated.Url != feed.Url || updated.FetchIntervalSeconds.Int32 != 3600 || updated.Enabled {
+		t.Errorf("Expected name, interval and enabled to change, got %+v", updated)
+	}
+
+	updated, err = s.UpdateFeed(ctx, database.UpdateFeedParams{ID: feed.ID, SetInterval: true})
+	if err != nil {
+		t.Fatalf("Expected UpdateFeed to succeed, got %v", err)
+	}
+	if updated.Name != "Example" || updated.FetchIntervalSeconds.Valid || updated.Enabled {
+		t.Errorf("Expected only the interval to be cleared, got %+v", updated)
+	}
+
+	withUsers, err := s.GetFeedsWithUsers(ctx)
+	if err != nil || len(withUsers) != 1 || withUsers[0].UserName != "alice" {
+		t.Errorf("Expected one feed added by alice, got %+v %v", withUsers, err)
+	}
+
+	if err := s.SetFeedFetchFullContent(ctx, database.SetFeedFetchFullContentParams{ID: feed.ID, FetchFullContent: true}); err != nil {
+		t.Fatalf("Expected SetFeedFetchFullContent to succeed, got %v", err)
+	}
+	retention := database.SetFeedRetentionParams{
+		ID:             feed.ID,
+		RetentionDays:  sql.NullInt32{Int32: 30, Valid: true},
+		RetentionPosts: sql.NullInt32{},
+	}
+	if err := s.SetFeedRetention(ctx, retention); err != nil {
+		t.Fatalf("Expected SetFeedRetention to succeed, got %v", err)
+	}
+	got, err := s.GetFeedByURL(ctx, feed.Url)
+	if err != nil {
+		t.Fatalf("Expected GetFeedByURL to succeed, got %v", err)
+	}
+	if !got.FetchFullContent || got.RetentionDays != retention.RetentionDays || got.RetentionPosts.Valid {
+		t.Errorf("Expected full content and a 30 day retention, got %+v", got)
+	}
+
+	// A feed that moved keeps its id, and the move is recorded.
+	moved := "https://example.com/moved"
+	if err := s.UpdateFeedURL(ctx, database.UpdateFeedURLParams{ID: feed.ID, Url: moved}); err != nil {
+		t.Fatalf("Expected UpdateFeedURL to succeed, got %v", err)
+	}
+	_, err = s.CreateFeedURLChange(ctx, database.CreateFeedURLChangeParams{FeedID: feed.ID, OldUrl: feed.Url, NewUrl: moved, Reason: "301"})
+	if err != nil {
+		t.Fatalf("Expected CreateFeedURLChange to succeed, got %v", err)
+	}
+	if _, err := s.GetFeedByURL(ctx, feed.Url); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows for the old url, got %v", err)
+	}
+	history, err := s.GetFeedURLHistory(ctx, feed.ID)
+	if err != nil || len(history) != 1 || history[0].OldUrl != feed.Url || history[0].NewUrl != moved {
+		t.Errorf("Expected the move in the url history, got %+v %v", history, err)
+	}
+
+	feeds, err := s.GetFeeds(ctx)
+	if err != nil || len(feeds) != 1 || feeds[0].Url != moved {
+		t.Errorf("Expected one feed at %s, got %+v %v", moved, feeds, err)
+	}
+}
+
+// testNextFeedToFetch checks that feeds are fetched least recently first,
+// skipping disabled feeds and feeds within their own interval.
+func testNextFeedToFetch(t *testing.T, s database.Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	a := createFeed(t, s, alice, "https://a.example.com/feed")
+	b := createFeed(t, s, alice, "https://b.example.com/feed")
+	disabled := createFeed(t, s, alice, "https://c.example.com/feed")
+	if _, err := s.UpdateFeed(ctx, database.UpdateFeedParams{ID: disabled.ID, Enabled: sql.NullBool{Valid: true}}); err != nil {
+		t.Fatalf("Expected UpdateFeed to succeed, got %v", err)
+	}
+
+	if err := s.MarkFeedFetched(ctx, a.ID); err != nil {
+		t.Fatalf("Expected MarkFeedFetched to succeed, got %v", err)
+	}
+	next, err := s.GetNextFeedToFetch(ctx)
+	if err != nil || next.ID != b.ID {
+		t.Fatalf("Expected the never-fetched feed next, got %s %v", next.Url, err)
+	}
+
+	if err := s.MarkFeedFetched(ctx, b.ID); err != nil {
+		t.Fatalf("Expected MarkFeedFetched to succeed, got %v", err)
+	}
+	next, err = s.GetNextFeedToFetch(ctx)
+	if err != nil || next.ID != a.ID {
+		t.Fatalf("Expected the least recently fetched feed next, got %s %v", next.Url, err)
+	}
+	if !next.LastFetchedAt.Valid {
+		t.Errorf("Expected last_fetched_at to be set")
+	}
+
+	for _, feed := range []database.Feed{a, b} {
+		_, err := s.UpdateFeed(ctx, database.UpdateFeedParams{ID: feed.ID, SetInterval: true, FetchIntervalSeconds: sql.NullInt32{Int32: 3600, Valid: true}})
+		if err != nil {
+			t.Fatalf("Expected UpdateFeed to succeed, got %v", err)
+		}
+	}
+	if next, err := s.GetNextFeedToFetch(ctx); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected no feed due within its interval, got %s %v", next.Url, err)
+	}
+}
+
+// testFollows checks following, listing and unfollowing by URL.
+func testFollows(t *testing.T, s database.Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	feed := createFeed(t, s, alice, "https://example.com/feed")
+
+	follow, err := s.CreateFeedFollow(ctx, database.CreateFeedFollowParams{FeedID: feed.ID, UserID: alice.ID})
+	if err != nil {
+		t.Fatalf("Expected CreateFeedFollow to succeed, got %v", err)
+	}
+	if follow.FeedName != feed.Name || follow.UserName != "alice" {
+		t.Errorf("Expected the feed and user names, got %q %q", follow.FeedName, follow.UserName)
+	}
+	_, err = s.CreateFeedFollow(ctx, database.CreateFeedFollowParams{FeedID: feed.ID, UserID: alice.ID})
+	if !database.IsUniqueViolation(err) {
+		t.Errorf("Expected a unique violation for a duplicate follow, got %v", err)
+	}
+
+	err = s.SetFeedFollowDisplayName(ctx, database.SetFeedFollowDisplayNameParams{ID: follow.ID, DisplayName: sql.NullString{String: "Mine", Valid: true}})
+	if err != nil {
+		t.Fatalf("Expected SetFeedFollowDisplayName to succeed, got %v", err)
+	}
+	follows, err := s.GetFeedFollowsForUser(ctx, alice.ID)
+	if err != nil || len(follows) != 1 || follows[0].FeedName != "Mine" || follows[0].FeedUrl != feed.Url {
+		t.Fatalf("Expected the follow under its display name, got %+v %v", follows, err)
+	}
+
+	if err := s.CreateFollowTag(ctx, database.CreateFollowTagParams{FeedFollowID: follow.ID, Tag: "go"}); err != nil {
+		t.Fatalf("Expected CreateFollowTag to succeed, got %v", err)
+	}
+	if err := s.CreateFollowTag(ctx, database.CreateFollowTagParams{FeedFollowID: follow.ID, Tag: "go"}); err != nil {
+		t.Errorf("Expected a duplicate tag to be ignored, got %v", err)
+	}
+	tags, err := s.GetFollowTagsForUser(ctx, alice.ID)
+	if err != nil || len(tags) != 1 || tags[0].Tag != "go" {
+		t.Errorf("Expected one tag, got %+v %v", tags, err)
+	}
+
+	post := createPost(t, s, feed, "https://example.com/post", time.Time{})
+	tagged, err := s.GetPostsForUserByTag(ctx, database.GetPostsForUserByTagParams{UserID: alice.ID, Tag: "go", Limit: 10})
+	if err != nil || len(tagged) != 1 || tagged[0].ID != post.ID || tagged[0].FeedName != "Mine" {
+		t.Errorf("Expected the post under the tag, got %+v %v", tagged, err)
+	}
+
+	if n, err := s.DeleteFollowTag(ctx, database.DeleteFollowTagParams{FeedFollowID: follow.ID, Tag: "go"}); err != nil || n != 1 {
+		t.Errorf("Expected to delete 1 tag, got %d %v", n, err)
+	}
+	tagged, err = s.GetPostsForUserByTag(ctx, database.GetPostsForUserByTagParams{UserID: alice.ID, Tag: "go", Limit: 10})
+	if err != nil || len(tagged) != 0 {
+		t.Errorf("Expected no posts once the tag is gone, got %+v %v", tagged, err)
+	}
+
+	got, err := s.GetFeedFollowForUser(ctx, database.GetFeedFollowForUserParams{UserID: alice.ID, Url: feed.Url})
+	if err != nil || got.ID != follow.ID {
+		t.Errorf("Expected GetFeedFollowForUser to find the follow, got %v %v", got.ID, err)
+	}
+
+	for _, want := range []int64{1, 0} {
+		n, err := s.DeleteFeedFollow(ctx, database.DeleteFeedFollowParams{UserID: alice.ID, Url: feed.Url})
+		if err != nil || n != want {
+			t.Errorf("Expected to unfollow %d, got %d %v", want, n, err)
+		}
+	}
+}
+
+// testPosts checks post creation, duplicate URLs and the order posts are
+// browsed in.
+func testPosts(t *testing.T, s database.Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	feed := createFeed(t, s, alice, "https://example.com/feed")
+	if _, err := s.CreateFeedFollow(ctx, database.CreateFeedFollowParams{FeedID: feed.ID, UserID: alice.ID}); err != nil {
+		t.Fatalf("Expected CreateFeedFollow to succeed, got %v", err)
+	}
+
+	published := time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)
+	post, err := s.CreatePost(ctx, database.CreatePostParams{
+		Title:       "Hello",
+		Url:         "https://example.com/hello",
+		Description: sql.NullString{String: "<p>Hi</p>", Valid: true},
+		PublishedAt: sql.NullTime{Time: published, Valid: true},
+		FeedID:      feed.ID,
+		Author:      sql.NullString{String: "Jane", Valid: true},
+		Categories:  []string{"go", "a, b"},
+	})
+	if err != nil {
+		t.Fatalf("Expected CreatePost to succeed, got %v", err)
+	}
+	if !post.PublishedAt.Time.Equal(published) || !slices.Equal(post.Categories, []string{"go", "a, b"}) || post.Author.String != "Jane" {
+		t.Errorf("Expected the post to round-trip, got %+v", post)
+	}
+
+	_, err = s.CreatePost(ctx, database.CreatePostParams{Title: "Again", Url: post.Url, FeedID: feed.ID, Categories: []string{}})
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows for a duplicate url, got %v", err)
+	}
+
+	createPost(t, s, feed, "https://example.com/newer", published.Add(time.Hour))
+	createPost(t, s, feed, "https://example.com/undated", time.Time{})
+
+	posts, err := s.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: alice.ID, Limit: 10})
+	if err != nil {
+		t.Fatalf("Expected GetPostsForUser to succeed, got %v", err)
+	}
+	got := postURLs(posts, func(p database.GetPostsForUserRow) string { return p.Url })
+	want := []string{"https://example.com/undated", "https://example.com/newer", "https://example.com/hello"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	posts, err = s.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: alice.ID, Limit: 1, Offset: 1})
+	if err != nil || len(posts) != 1 || posts[0].Url != want[1] {
+		t.Errorf("Expected the second post, got %+v %v", posts, err)
+	}
+
+	if err := s.MarkPostRead(ctx, database.MarkPostReadParams{UserID: alice.ID, PostID: post.ID}); err != nil {
+		t.Fatalf("Expected MarkPostRead to succeed, got %v", err)
+	}
+	posts, err = s.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: alice.ID, Limit: 10})
+	if err != nil {
+		t.Fatalf("Expected GetPostsForUser to succeed, got %v", err)
+	}
+	for _, p := range posts {
+		if p.ReadAt.Valid != (p.ID == post.ID) {
+			t.Errorf("Expected only %s to be read, got %s read_at %v", post.Url, p.Url, p.ReadAt)
+		}
+	}
+
+	content := sql.NullString{String: "<p>Full text</p>", Valid: true}
+	if err := s.SetPostContent(ctx, database.SetPostContentParams{ID: post.ID, Content: content}); err != nil {
+		t.Fatalf("Expected SetPostContent to succeed, got %v", err)
+	}
+	posts, err = s.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: alice.ID, Limit: 1, Offset: 2})
+	if err != nil || len(posts) != 1 || posts[0].Content != content {
+		t.Errorf("Expected the full content, got %+v %v", posts, err)
+	}
+
+	// Posts without a date tie, so pages must still neither repeat nor
+	// skip any of them.
+	for i := range 5 {
+		createPost(t, s, feed, fmt.Sprintf("https://example.com/undated-%d", i), time.Time{})
+	}
+	seen := map[string]bool{}
+	for offset := int32(0); offset < 8; offset += 2 {
+		page, err := s.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: alice.ID, Limit: 2, Offset: offset})
+		if err != nil {
+			t.Fatalf("Expected GetPostsForUser to succeed, got %v", err)
+		}
+		for _, p := range page {
+			if seen[p.Url] {
+				t.Errorf("Expected %s on one page only", p.Url)
+			}
+			seen[p.Url] = true
+		}
+	}
+	if len(seen) != 8 {
+		t.Errorf("Expected to page through 8 posts, got %d", len(seen))
+	}
+}
+
+// testRetention checks the posts retention prunes, and that saved posts are
+// kept.
+func testRetention(t *testing.T, s database.Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	feed := createFeed(t, s, alice, "https://example.com/feed")
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	oldest := createPost(t, s, feed, "https://example.com/1", base)
+	saved := createPost(t, s, feed, "https://example.com/2", base.Add(24*time.Hour))
+	third := createPost(t, s, feed, "https://example.com/3", base.Add(48*time.Hour))
+	createPost(t, s, feed, "https://example.com/4", base.Add(72*time.Hour))
+	if err := s.SavePost(ctx, database.SavePostParams{UserID: alice.ID, PostID: saved.ID}); err != nil {
+		t.Fatalf("Expected SavePost to succeed, got %v", err)
+	}
+
+	url := func(p database.Post) string { return p.Url }
+	tests := []struct {
+		name   string
+		params database.GetPrunablePostsParams
+		want   []string
+	}{
+		{"keep", database.GetPrunablePostsParams{Keep: sql.NullInt32{Int32: 1, Valid: true}}, []string{"https://example.com/1", "https://example.com/3"}},
+		{"cutoff", database.GetPrunablePostsParams{Cutoff: sql.NullTime{Time: base.Add(36 * time.Hour), Valid: true}}, []string{"https://example.com/1"}},
+		{"none", database.GetPrunablePostsParams{}, []string{}},
+	}
+	for _, tt := range tests {
+		tt.params.FeedID = feed.ID
+		posts, err := s.GetPrunablePosts(ctx, tt.params)
+		if err != nil {
+			t.Fatalf("%s: expected GetPrunablePosts to succeed, got %v", tt.name, err)
+		}
+		if got := postURLs(posts, url); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+
+	if err := s.CreatePrunedPosts(ctx, []uuid.UUID{oldest.ID}); err != nil {
+		t.Fatalf("Expected CreatePrunedPosts to succeed, got %v", err)
+	}
+	if n, err := s.DeletePostsByID(ctx, []uuid.UUID{oldest.ID, uuid.New()}); err != nil || n != 1 {
+		t.Errorf("Expected to delete 1 post by id, got %d %v", n, err)
+	}
+
+	// The next fetch still lists the pruned post; it must not come back.
+	_, err := s.CreatePost(ctx, database.CreatePostParams{Title: "Again", Url: oldest.Url, FeedID: feed.ID, Categories: []string{}})
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows for a pruned url, got %v", err)
+	}
+
+	// purge-posts takes unsaved posts older than a cutoff the same way.
+	ids, err := s.GetPostIDsOlderThan(ctx, database.GetPostIDsOlderThanParams{
+		Cutoff: base.Add(60 * time.Hour),
+		FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true},
+	})
+	if err != nil || !slices.Equal(ids, []uuid.UUID{third.ID}) {
+		t.Fatalf("Expected the one unsaved old post, got %v %v", ids, err)
+	}
+	if err := s.CreatePrunedPosts(ctx, ids); err != nil {
+		t.Fatalf("Expected CreatePrunedPosts to succeed, got %v", err)
+	}
+	if n, err := s.DeletePostsByID(ctx, ids); err != nil || n != 1 {
+		t.Errorf("Expected to purge 1 post, got %d %v", n, err)
+	}
+	_, err = s.CreatePost(ctx, database.CreatePostParams{Title: "Again", Url: third.Url, FeedID: feed.ID, Categories: []string{}})
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows for a purged url, got %v", err)
+	}
+
+	counts, err := s.CountFeedData(ctx, feed.ID)
+	if err != nil || counts.Posts != 2 {
+		t.Errorf("Expected 2 posts left, got %d %v", counts.Posts, err)
+	}
+
+	// Forgotten URLs may be stored again.
+	forget := database.DeletePrunedPostsBeforeParams{FeedID: feed.ID, Cutoff: time.Now().Add(-time.Hour)}
+	if n, err := s.DeletePrunedPostsBefore(ctx, forget); err != nil || n != 0 {
+		t.Errorf("Expected to keep recently pruned urls, got %d %v", n, err)
+	}
+	forget.Cutoff = time.Now().Add(time.Hour)
+	if n, err := s.DeletePrunedPostsBefore(ctx, forget); err != nil || n != 2 {
+		t.Errorf("Expected to forget 2 pruned urls, got %d %v", n, err)
+	}
+	createPost(t, s, feed, oldest.Url, base)
+}
+
+// testDigest checks that digests cover unread posts created in their window.
+func testDigest(t *testing.T, s database.Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	feed := createFeed(t, s, alice, "https://example.com/feed")
+	if _, err := s.CreateFeedFollow(ctx, database.CreateFeedFollowParams{FeedID: feed.ID, UserID: alice.ID}); err != nil {
+		t.Fatalf("Expected CreateFeedFollow to succeed, got %v", err)
+	}
+	since := time.Now().Add(-time.Minute)
+	unread := createPost(t, s, feed, "https://example.com/unread", time.Time{})
+	read := createPost(t, s, feed, "https://example.com/read", time.Time{})
+	if err := s.MarkPostRead(ctx, database.MarkPostReadParams{UserID: alice.ID, PostID: read.ID}); err != nil {
+		t.Fatalf("Expected MarkPostRead to succeed, got %v", err)
+	}
+
+	posts, err := s.GetDigestPostsForUser(ctx, database.GetDigestPostsForUserParams{
+		UserID:   alice.ID,
+		Since:    since,
+		Until:    time.Now().Add(time.Minute),
+		PageSize: 10,
+	})
+	if err != nil || len(posts) != 1 || posts[0].ID != unread.ID {
+		t.Errorf("Expected only the unread post, got %+v %v", posts, err)
+	}
+
+	posts, err = s.GetDigestPostsForUser(ctx, database.GetDigestPostsForUserParams{
+		UserID:   alice.ID,
+		Since:    since.Add(-time.Hour),
+		Until:    since,
+		PageSize: 10,
+	})
+	if err != nil || len(posts) != 0 {
+		t.Errorf("Expected no posts before the window, got %+v %v", posts, err)
+	}
+}
+
+// testDigestPaging checks that a digest larger than a page is read in
+// creation order without skipping or repeating posts.
+func testDigestPaging(t *testing.T, s database.Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	feed := createFeed(t, s, alice, "https://example.com/feed")
+	if _, err := s.CreateFeedFollow(ctx, database.CreateFeedFollowParams{FeedID: feed.ID, UserID: alice.ID}); err != nil {
+		t.Fatalf("Expected CreateFeedFollow to succeed, got %v", err)
+	}
+
+	since := time.Now().Add(-time.Minute)
+	var created []database.Post
+	for i := range 250 {
+		created = append(created, createPost(t, s, feed, fmt.Sprintf("https://example.com/%d", i), time.Time{}))
+	}
+	want := postURLs(created, func(p database.Post) string { return p.Url })
+
+	params := database.GetDigestPostsForUserParams{
+		UserID:   alice.ID,
+		Since:    since,
+		Until:    time.Now().Add(time.Minute),
+		PageSize: 100,
+	}
+	var got []string
+	for {
+		page, err := s.GetDigestPostsForUser(ctx, params)
+		if err != nil {
+			t.Fatalf("Expected GetDigestPostsForUser to succeed, got %v", err)
+		}
+		for _, post := range page {
+			got = append(got, post.Url)
+			params.Since = post.CreatedAt
+			params.AfterID = uuid.NullUUID{UUID: post.ID, Valid: true}
+		}
+		if len(page) < int(params.PageSize) {
+			break
+		}
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected all %d posts in creation order, got %d: %v", len(want), len(got), got)
+	}
+
+	// A later digest starting at the 200th post gets the rest.
+	rest, err := s.GetDigestPostsForUser(ctx, database.GetDigestPostsForUserParams{
+		UserID:   alice.ID,
+		Since:    created[199].CreatedAt,
+		Until:    params.Until,
+		PageSize: 100,
+	})
+	if err != nil || len(rest) != 50 || rest[0].Url != want[200] {
+		t.Errorf("Expected the last 50 posts, got %d %v", len(rest), err)
+	}
+}
+
+// testFeedFetches checks the fetch log is listed newest first and pruned by
+// age.
+func testFeedFetches(t *testing.T, s database.Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	feed := createFeed(t, s, alice, "https://example.com/feed")
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	for i := range 3 {
+		started := base.Add(time.Duration(i) * time.Hour)
+		_, err := s.CreateFeedFetch(ctx, database.CreateFeedFetchParams{
+			FeedID:     feed.ID,
+			StartedAt:  started,
+			FinishedAt: started.Add(time.Second),
+			HttpStatus: sql.NullInt32{Int32: 200, Valid: true},
+			Bytes:      1 << 40,
+			Items:      int32(i),
+		})
+		if err != nil {
+			t.Fatalf("Expected CreateFeedFetch to succeed, got %v", err)
+		}
+	}
+
+	fetches, err := s.GetFeedFetchesForFeed(ctx, database.GetFeedFetchesForFeedParams{Url: feed.Url, Limit: 2})
+	if err != nil || len(fetches) != 2 {
+		t.Fatalf("Expected 2 fetches, got %d %v", len(fetches), err)
+	}
+	if fetches[0].Items != 2 || fetches[0].Bytes != 1<<40 || !fetches[0].StartedAt.Equal(base.Add(2*time.Hour)) {
+		t.Errorf("Expected the newest fetch first, got %+v", fetches[0])
+	}
+
+	all, err := s.GetFeedFetches(ctx, 10)
+	if err != nil || len(all) != 3 || all[0].FeedUrl != feed.Url || all[0].Items != 2 {
+		t.Errorf("Expected 3 fetches newest first, got %+v %v", all, err)
+	}
+
+	n, err := s.DeleteFeedFetchesBefore(ctx, base.Add(90*time.Minute))
+	if err != nil || n != 2 {
+		t.Errorf("Expected to prune 2 fetches, got %d %v", n, err)
+	}
+}
+
+// testWebhooks checks which webhooks fire for a feed, and that deliveries are
+// logged for their owner.
+func testWebhooks(t *testing.T, s database.Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+	feed := createFeed(t, s, alice, "https://example.com/feed")
+	other := createFeed(t, s, alice, "https://example.com/other")
+	follow, err := s.CreateFeedFollow(ctx, database.CreateFeedFollowParams{FeedID: feed.ID, UserID: alice.ID})
+	if err != nil {
+		t.Fatalf("Expected CreateFeedFollow to succeed, got %v", err)
+	}
+	if err := s.CreateFollowTag(ctx, database.CreateFollowTagParams{FeedFollowID: follow.ID, Tag: "go"}); err != nil {
+		t.Fatalf("Expected CreateFollowTag to succeed, got %v", err)
+	}
+
+	create := func(params database.CreateWebhookParams) database.Webhook {
+		t.Helper()
+		params.UserID = alice.ID
+		params.Secret = "secret"
+		hook, err := s.CreateWebhook(ctx, params)
+		if err != nil {
+			t.Fatalf("Expected CreateWebhook to succeed, got %v", err)
+		}
+		return hook
+	}
+	all := create(database.CreateWebhookParams{Url: "https://hooks.example.com/all"})
+	tagged := create(database.CreateWebhookParams{Url: "https://hooks.example.com/go", Tag: sql.NullString{String: "go", Valid: true}})
+	create(database.CreateWebhookParams{Url: "https://hooks.example.com/rust", Tag: sql.NullString{String: "rust", Valid: true}})
+	create(database.CreateWebhookParams{Url: "https://hooks.example.com/other", FeedID: uuid.NullUUID{UUID: other.ID, Valid: true}})
+
+	hooks, err := s.GetWebhooksForFeed(ctx, feed.ID)
+	if err != nil {
+		t.Fatalf("Expected GetWebhooksForFeed to succeed, got %v", err)
+	}
+	urls := postURLs(hooks, func(h database.GetWebhooksForFeedRow) string { return h.Url })
+	slices.Sort(urls)
+	if want := []string{all.Url, tagged.Url}; !slices.Equal(urls, want) {
+		t.Errorf("Expected %v, got %v", want, urls)
+	}
+	if hooks, err := s.GetWebhooksForFeed(ctx, other.ID); err != nil || len(hooks) != 0 {
+		t.Errorf("Expected no webhooks for an unfollowed feed, got %+v %v", hooks, err)
+	}
+
+	post := createPost(t, s, feed, "https://example.com/post", time.Time{})
+	err = s.CreateWebhookDelivery(ctx, database.CreateWebhookDeliveryParams{
+		WebhookID:  all.ID,
+		PostID:     uuid.NullUUID{UUID: post.ID, Valid: true},
+		Attempts:   3,
+		StatusCode: sql.NullInt32{Int32: 500, Valid: true},
+		Error:      sql.NullString{String: "unexpected status", Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("Expected CreateWebhookDelivery to succeed, got %v", err)
+	}
+	deliveries, err := s.GetWebhookDeliveriesForUser(ctx, database.GetWebhookDeliveriesForUserParams{UserID: alice.ID, Limit: 10})
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("Expected 1 delivery, got %d %v", len(deliveries), err)
+	}
+	if d := deliveries[0]; d.WebhookUrl != all.Url || d.PostTitle.String != post.Title || d.Attempts != 3 || d.StatusCode.Int32 != 500 {
+		t.Errorf("Expected the failed delivery, got %+v", d)
+	}
+
+	if n, err := s.DeleteWebhook(ctx, database.DeleteWebhookParams{UserID: bob.ID, ID: all.ID}); err != nil || n != 0 {
+		t.Errorf("Expected bob not to delete alice's webhook, got %d %v", n, err)
+	}
+	if n, err := s.DeleteWebhook(ctx, database.DeleteWebhookParams{UserID: alice.ID, ID: all.ID}); err != nil || n != 1 {
+		t.Errorf("Expected to delete 1 webhook, got %d %v", n, err)
+	}
+	deliveries, err = s.GetWebhookDeliveriesForUser(ctx, database.GetWebhookDeliveriesForUserParams{UserID: alice.ID, Limit: 10})
+	if err != nil || len(deliveries) != 0 {
+		t.Errorf("Expected deliveries to go with their webhook, got %d %v", len(deliveries), err)
+	}
+}
+
+// testFilters checks that filter rules can only be deleted by their owner.
+func testFilters(t *testing.T, s database.Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+	rule, err := s.CreateFilterRule(ctx, database.CreateFilterRuleParams{
+		UserID:  alice.ID,
+		Field:   "title",
+		Pattern: "sponsored",
+		Action:  "hide",
+	})
+	if err != nil {
+		t.Fatalf("Expected CreateFilterRule to succeed, got %v", err)
+	}
+
+	if n, err := s.DeleteFilterRule(ctx, database.DeleteFilterRuleParams{UserID: bob.ID, ID: rule.ID}); err != nil || n != 0 {
+		t.Errorf("Expected bob not to delete alice's filter, got %d %v", n, err)
+	}
+	if n, err := s.DeleteFilterRule(ctx, database.DeleteFilterRuleParams{UserID: alice.ID, ID: rule.ID}); err != nil || n != 1 {
+		t.Errorf("Expected to delete 1 filter, got %d %v", n, err)
+	}
+	rules, err := s.GetFilterRulesForUser(ctx, alice.ID)
+	if err != nil || len(rules) != 0 {
+		t.Errorf("Expected no filters left, got %d %v", len(rules), err)
+	}
+}
+
+// testCascade checks that deleting a user removes everything they added.
+func testCascade(t *testing.T, s database.Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+	feed := createFeed(t, s, alice, "https://example.com/feed")
+	createPost(t, s, feed, "https://example.com/post", time.Time{})
+	if _, err := s.CreateFeedFollow(ctx, database.CreateFeedFollowParams{FeedID: feed.ID, UserID: bob.ID}); err != nil {
+		t.Fatalf("Expected CreateFeedFollow to succeed, got %v", err)
+	}
+
+	counts, err := s.CountUserData(ctx, alice.ID)
+	if err != nil || counts.Feeds != 1 || counts.Posts != 1 {
+		t.Errorf("Expected alice to have 1 feed and 1 post, got %+v %v", counts, err)
+	}
+	if n, err := s.DeleteUser(ctx, alice.ID); err != nil || n != 1 {
+		t.Fatalf("Expected to delete alice, got %d %v", n, err)
+	}
+
+	all, err := s.CountAllData(ctx)
+	if err != nil {
+		t.Fatalf("Expected CountAllData to succeed, got %v", err)
+	}
+	if all.Users != 1 || all.Feeds != 0 || all.Follows != 0 || all.Posts != 0 {
+		t.Errorf("Expected only bob to remain, got %+v", all)
+	}
+}
+
+// testMergeFeed runs the steps merging one feed into another, in the order
+// the merge command does, and checks that nothing belonging to the first feed
+// is lost when it is deleted.
+func testMergeFeed(t *testing.T, s database.Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+	from := createFeed(t, s, alice, "https://example.com/old")
+	into := createFeed(t, s, alice, "https://example.com/new")
+
+	// alice follows only the old feed, with a tag; bob follows both.
+	follow, err := s.CreateFeedFollow(ctx, database.CreateFeedFollowParams{FeedID: from.ID, UserID: alice.ID})
+	if err != nil {
+		t.Fatalf("Expected CreateFeedFollow to succeed, got %v", err)
+	}
+	if err := s.CreateFollowTag(ctx, database.CreateFollowTagParams{FeedFollowID: follow.ID, Tag: "go"}); err != nil {
+		t.Fatalf("Expected CreateFollowTag to succeed, got %v", err)
+	}
+	for _, feed := range []database.Feed{from, into} {
+		if _, err := s.CreateFeedFollow(ctx, database.CreateFeedFollowParams{FeedID: feed.ID, UserID: bob.ID}); err != nil {
+			t.Fatalf("Expected CreateFeedFollow to succeed, got %v", err)
+		}
+	}
+
+	post := createPost(t, s, from, "https://example.com/post", time.Time{})
+	pruned := createPost(t, s, from, "https://example.com/pruned", time.Time{})
+	if err := s.CreatePrunedPosts(ctx, []uuid.UUID{pruned.ID}); err != nil {
+		t.Fatalf("Expected CreatePrunedPosts to succeed, got %v", err)
+	}
+	if _, err := s.DeletePostsByID(ctx, []uuid.UUID{pruned.ID}); err != nil {
+		t.Fatalf("Expected DeletePostsByID to succeed, got %v", err)
+	}
+
+	now := time.Now()
+	if _, err := s.CreateFeedFetch(ctx, database.CreateFeedFetchParams{FeedID: from.ID, StartedAt: now, FinishedAt: now}); err != nil {
+		t.Fatalf("Expected CreateFeedFetch to succeed, got %v", err)
+	}
+	if _, err := s.CreateFeedURLChange(ctx, database.CreateFeedURLChangeParams{FeedID: from.ID, OldUrl: "https://example.com/older", NewUrl: from.Url, Reason: "301"}); err != nil {
+		t.Fatalf("Expected CreateFeedURLChange to succeed, got %v", err)
+	}
+
+	hook, err := s.CreateWebhook(ctx, database.CreateWebhookParams{
+		UserID: alice.ID,
+		Url:    "https://hooks.example.com",
+		Secret: "secret",
+		FeedID: uuid.NullUUID{UUID: from.ID, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("Expected CreateWebhook to succeed, got %v", err)
+	}
+	rule, err := s.CreateFilterRule(ctx, database.CreateFilterRuleParams{
+		UserID:  alice.ID,
+		Field:   "title",
+		Pattern: "sponsored",
+		Action:  "hide",
+		FeedID:  uuid.NullUUID{UUID: from.ID, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("Expected CreateFilterRule to succeed, got %v", err)
+	}
+
+	steps := []struct {
+		name string
+		fn   func() error
+	}{
+		{"MoveFeedFollows", func() error {
+			return s.MoveFeedFollows(ctx, database.MoveFeedFollowsParams{ToFeedID: into.ID, FromFeedID: from.ID})
+		}},
+		{"MoveFollowTags", func() error {
+			return s.MoveFollowTags(ctx, database.MoveFollowTagsParams{ToFeedID: into.ID, FromFeedID: from.ID})
+		}},
+		{"MovePosts", func() error {
+			return s.MovePosts(ctx, database.MovePostsParams{ToFeedID: into.ID, FromFeedID: from.ID})
+		}},
+		{"MoveFeedFetches", func() error {
+			return s.MoveFeedFetches(ctx, database.MoveFeedFetchesParams{ToFeedID: into.ID, FromFeedID: from.ID})
+		}},
+		{"MoveFeedURLHistory", func() error {
+			return s.MoveFeedURLHistory(ctx, database.MoveFeedURLHistoryParams{ToFeedID: into.ID, FromFeedID: from.ID})
+		}},
+		{"MovePrunedPosts", func() error {
+			return s.MovePrunedPosts(ctx, database.MovePrunedPostsParams{ToFeedID: into.ID, FromFeedID: from.ID})
+		}},
+		{"MoveFilterRules", func() error {
+			return s.MoveFilterRules(ctx, database.MoveFilterRulesParams{ToFeedID: into.ID, FromFeedID: from.ID})
+		}},
+		{"MoveWebhooks", func() error {
+			return s.MoveWebhooks(ctx, database.MoveWebhooksParams{ToFeedID: into.ID, FromFeedID: from.ID})
+		}},
+		{"MarkFeedFetched", func() error { return s.MarkFeedFetched(ctx, into.ID) }},
+		{"DeleteFeed", func() error { return s.DeleteFeed(ctx, from.ID) }},
+	}
+	for _, step := range steps {
+		if err := step.fn(); err != nil {
+			t.Fatalf("Expected %s to succeed, got %v", step.name, err)
+		}
+	}
+
+	counts, err := s.CountFeedData(ctx, into.ID)
+	if err != nil || counts.Follows != 2 || counts.Posts != 1 {
+		t.Errorf("Expected 2 follows and 1 post, got %+v %v", counts, err)
+	}
+	tags, err := s.GetFollowTagsForUser(ctx, alice.ID)
+	if err != nil || len(tags) != 1 || tags[0].FeedID != into.ID || tags[0].Tag != "go" {
+		t.Errorf("Expected alice's tag on %s, got %+v %v", into.Url, tags, err)
+	}
+	posts, err := s.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: alice.ID, Limit: 10})
+	if err != nil || len(posts) != 1 || posts[0].ID != post.ID {
+		t.Errorf("Expected alice to still see the post, got %+v %v", posts, err)
+	}
+	_, err = s.CreatePost(ctx, database.CreatePostParams{Title: "Again", Url: pruned.Url, FeedID: into.ID, Categories: []string{}})
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected the pruned url to stay pruned, got %v", err)
+	}
+
+	fetches, err := s.GetFeedFetchesForFeed(ctx, database.GetFeedFetchesForFeedParams{Url: into.Url, Limit: 10})
+	if err != nil || len(fetches) != 1 {
+		t.Errorf("Expected the fetch log to move, got %d %v", len(fetches), err)
+	}
+	history, err := s.GetFeedURLHistory(ctx, into.ID)
+	if err != nil || len(history) != 1 || history[0].NewUrl != from.Url {
+		t.Errorf("Expected the url history to move, got %+v %v", history, err)
+	}
+	merged, err := s.GetFeedByURL(ctx, into.Url)
+	if err != nil || !merged.LastFetchedAt.Valid {
+		t.Errorf("Expected %s to be marked fetched, got %v %v", into.Url, merged.LastFetchedAt, err)
+	}
+
+	hooks, err := s.GetWebhooksForUser(ctx, alice.ID)
+	if err != nil || len(hooks) != 1 {
+		t.Fatalf("Expected the webhook to survive, got %d %v", len(hooks), err)
+	}
+	if hooks[0].ID != hook.ID || hooks[0].FeedID.UUID != into.ID {
+		t.Errorf("Expected the webhook on %s, got %+v", into.Url, hooks[0])
+	}
+
+	rules, err := s.GetFilterRulesForUser(ctx, alice.ID)
+	if err != nil || len(rules) != 1 {
+		t.Fatalf("Expected the filter to survive, got %d %v", len(rules), err)
+	}
+	if rules[0].ID != rule.ID || rules[0].FeedID.UUID != into.ID {
+		t.Errorf("Expected the filter on %s, got %+v", into.Url, rules[0])
+	}
+}
+
+// testTransactions checks that InTx commits on success and rolls back on
+// error.
+func testTransactions(t *testing.T, s database.Store) {
+	ctx := context.Background()
+	failed := errors.New("failed")
+	err := s.InTx(ctx, func(q database.Querier) error {
+		createUser(t, q, "alice")
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Errorf("Expected the error from fn, got %v", err)
+	}
+	if _, err := s.GetUser(ctx, "alice"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected the user to be rolled back, got %v", err)
+	}
+
+	err = s.InTx(ctx, func(q database.Querier) error {
+		createUser(t, q, "bob")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected InTx to succeed, got %v", err)
+	}
+	if _, err := s.GetUser(ctx, "bob"); err != nil {
+		t.Errorf("Expected the user to be committed, got %v", err)
+	}
+}
@@ -7,29 +7,43 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const countAdmins = `-- name: CountAdmins :one
+SELECT count(*) FROM users WHERE role = 'admin'
+`
+
+func (q *Queries) CountAdmins(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAdmins)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name)
+INSERT INTO users (id, created_at, updated_at, name, role)
 VALUES (
     $1,
     $2,
     $3,
-    $4
+    $4,
+    CASE WHEN EXISTS (SELECT 1 FROM users WHERE role = 'admin') THEN 'member' ELSE 'admin' END
 )
-RETURNING id, created_at, updated_at, name
+RETURNING id, created_at, updated_at, name, email, last_digest_at, role
 `
 
 type CreateUserParams struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
 }
 
+// The first user becomes an admin so a fresh install can be managed.
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
 	row := q.db.QueryRowContext(ctx, createUser,
 		arg.ID,
@@ -43,12 +57,15 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.Email,
+		&i.LastDigestAt,
+		&i.Role,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, created_at, updated_at, name FROM users 
+SELECT id, created_at, updated_at, name, email, last_digest_at, role FROM users 
 WHERE name = $1
 `
 
@@ -60,12 +77,15 @@ func (q *Queries) GetUser(ctx context.Context, name string) (User, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.Email,
+		&i.LastDigestAt,
+		&i.Role,
 	)
 	return i, err
 }
 
 const getUserById = `-- name: GetUserById :one
-SELECT id, created_at, updated_at, name FROM users
+SELECT id, created_at, updated_at, name, email, last_digest_at, role FROM users
 WHERE id = $1
 `
 
@@ -77,12 +97,15 @@ func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.Email,
+		&i.LastDigestAt,
+		&i.Role,
 	)
 	return i, err
 }
 
 const getUsers = `-- name: GetUsers :many
-SELECT id, created_at, updated_at, name FROM users
+SELECT id, created_at, updated_at, name, email, last_digest_at, role FROM users
 `
 
 func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
@@ -99,6 +122,9 @@ func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Name,
+			&i.Email,
+			&i.LastDigestAt,
+			&i.Role,
 		); err != nil {
 			return nil, err
 		}
@@ -121,3 +147,51 @@ func (q *Queries) ResetUsers(ctx context.Context) error {
 	_, err := q.db.ExecContext(ctx, resetUsers)
 	return err
 }
+
+const setUserEmail = `-- name: SetUserEmail :exec
+UPDATE users
+SET email = $2, updated_at = now()
+WHERE id = $1
+`
+
+type SetUserEmailParams struct {
+	ID    uuid.UUID      `json:"id"`
+	Email sql.NullString `json:"email"`
+}
+
+func (q *Queries) SetUserEmail(ctx context.Context, arg SetUserEmailParams) error {
+	_, err := q.db.ExecContext(ctx, setUserEmail, arg.ID, arg.Email)
+	return err
+}
+
+const setUserLastDigestAt = `-- name: SetUserLastDigestAt :exec
+UPDATE users
+SET last_digest_at = $2
+WHERE id = $1
+`
+
+type SetUserLastDigestAtParams struct {
+	ID           uuid.UUID    `json:"id"`
+	LastDigestAt sql.NullTime `json:"last_digest_at"`
+}
+
+func (q *Queries) SetUserLastDigestAt(ctx context.Context, arg SetUserLastDigestAtParams) error {
+	_, err := q.db.ExecContext(ctx, setUserLastDigestAt, arg.ID, arg.LastDigestAt)
+	return err
+}
+
+const setUserRole = `-- name: SetUserRole :exec
+UPDATE users
+SET role = $2, updated_at = now()
+WHERE id = $1
+`
+
+type SetUserRoleParams struct {
+	ID   uuid.UUID `json:"id"`
+	Role string    `json:"role"`
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) error {
+	_, err := q.db.ExecContext(ctx, setUserRole, arg.ID, arg.Role)
+	return err
+}
@@ -0,0 +1,228 @@
+// Package digest renders a user's new posts into a text and HTML email and
+// sends it over SMTP.
+package digest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Post is one entry in a digest. Summary is plain text.
+type Post struct {
+	Title       string
+	URL         string
+	Summary     string
+	Author      string
+	PublishedAt time.Time
+	Highlighted bool
+}
+
+// Feed groups the posts of one followed feed.
+type Feed struct {
+	Name  string
+	Posts []Post
+}
+
+// Digest is the data templates are rendered from.
+type Digest struct {
+	User  string
+	Since time.Time
+	Until time.Time
+	Feeds []Feed
+	Total int
+}
+
+const defaultSubject = `Your gator digest: {{.Total}} new post{{if ne .Total 1}}s{{end}}`
+
+const defaultText = `Hi {{.User}},
+
+{{.Total}} new post{{if ne .Total 1}}s{{end}} since {{.Since.Format "Mon Jan 2 15:04"}}.
+{{range .Feeds}}
+== {{.Name}} ==
+{{range .Posts}}
+{{if .Highlighted}}* {{end}}{{.Title}}
+{{.URL}}
+{{if .Summary}}{{.Summary}}
+{{end}}{{end}}{{end}}
+-- 
+Sent by gator. Run 'gator digest email off' to stop these emails.
+`
+
+const defaultHTML = `<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; max-width: 40em;">
+<p>Hi {{.User}},</p>
+<p>{{.Total}} new post{{if ne .Total 1}}s{{end}} since {{.Since.Format "Mon Jan 2 15:04"}}.</p>
+{{range .Feeds}}
+<h2>{{.Name}}</h2>
+{{range .Posts}}
+<div style="margin-bottom: 1em;">
+<a href="{{.URL}}"><strong>{{if .Highlighted}}&#9733; {{end}}{{.Title}}</strong></a>
+{{if .Author}}<br><small>{{.Author}}</small>{{end}}
+{{if .Summary}}<p>{{.Summary}}</p>{{end}}
+</div>
+{{end}}
+{{end}}
+<p><small>Sent by gator. Run <code>gator digest email off</code> to stop these emails.</small></p>
+</body>
+</html>
+`
+
+// Templates render the subject, plain text and HTML parts of a digest.
+type Templates struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+// LoadTemplates returns the built-in templates, with the text and HTML bodies
+// replaced by the files at textPath and htmlPath when they are not empty.
+func LoadTemplates(textPath, htmlPath string) (*Templates, error) {
+	textSrc, htmlSrc := defaultText, defaultHTML
+	if textPath != "" {
+		data, err := os.ReadFile(textPath)
+		if err != nil {
+			return nil, err
+		}
+		textSrc = string(data)
+	}
+	if htmlPath != "" {
+		data, err := os.ReadFile(htmlPath)
+		if err != nil {
+			return nil, err
+		}
+		htmlSrc = string(data)
+	}
+
+	subject, err := texttemplate.New("subject").Parse(defaultSubject)
+	if err != nil {
+		return nil, err
+	}
+	text, err := texttemplate.New("text").Parse(textSrc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid text template: %v", err)
+	}
+	html, err := htmltemplate.New("html").Parse(htmlSrc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid HTML template: %v", err)
+	}
+	return &Templates{subject: subject, text: text, html: html}, nil
+}
+
+// Message is a rendered email.
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	Date    time.Time
+}
+
+// Render builds the message for d.
+func (t *Templates) Render(d Digest, from, to string) (Message, error) {
+	var subject, text, html bytes.Buffer
+	if err := t.subject.Execute(&subject, d); err != nil {
+		return Message{}, err
+	}
+	if err := t.text.Execute(&text, d); err != nil {
+		return Message{}, fmt.Errorf("rendering text template: %v", err)
+	}
+	if err := t.html.Execute(&html, d); err != nil {
+		return Message{}, fmt.Errorf("rendering HTML template: %v", err)
+	}
+
+	return Message{
+		From:    from,
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    text.String(),
+		HTML:    html.String(),
+		Date:    d.Until,
+	}, nil
+}
+
+// Bytes encodes m as a multipart/alternative MIME message.
+func (m Message) Bytes() []byte {
+	var b bytes.Buffer
+
+	boundary := randomBoundary()
+	fmt.Fprintf(&b, "From: %s\r\n", m.From)
+	fmt.Fprintf(&b, "To: %s\r\n", m.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", m.Date.Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%q\r\n", boundary)
+	fmt.Fprintf(&b, "\r\n")
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		fmt.Fprintf(&b, "--%s\r\n", boundary)
+		fmt.Fprintf(&b, "Content-Type: %s\r\n", part.contentType)
+		fmt.Fprintf(&b, "Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		w := quotedprintable.NewWriter(&b)
+		w.Write([]byte(strings.ReplaceAll(part.body, "\n", "\r\n")))
+		w.Close()
+		fmt.Fprintf(&b, "\r\n")
+	}
+	fmt.Fprintf(&b, "--%s--\r\n", boundary)
+
+	return b.Bytes()
+}
+
+func randomBoundary() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return "gator-" + hex.EncodeToString(buf)
+}
+
+// Mailer sends messages through an SMTP server. STARTTLS is used when the
+// server offers it, and credentials are only sent when Username is set.
+type Mailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
+// Send delivers m.
+func (s Mailer) Send(m Message) error {
+	if s.Host == "" {
+		return fmt.Errorf("no SMTP host configured")
+	}
+	port := s.Port
+	if port == 0 {
+		port = 587
+	}
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(port))
+	return smtp.SendMail(addr, auth, addressOnly(m.From), []string{addressOnly(m.To)}, m.Bytes())
+}
+
+// addressOnly strips a display name, as in "Gator <gator@example.com>".
+func addressOnly(addr string) string {
+	if parsed, err := mail.ParseAddress(addr); err == nil {
+		return parsed.Address
+	}
+	return addr
+}
@@ -0,0 +1,185 @@
+package digest_test
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eefret/gator/internal/digest"
+)
+
+var testDigest = digest.Digest{
+	User:  "alice",
+	Since: time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC),
+	Until: time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC),
+	Feeds: []digest.Feed{{
+		Name: "Go Blog",
+		Posts: []digest.Post{
+			{Title: "Go 1.22 <released>", URL: "https://go.dev/blog/go1.22", Summary: "Loop variables & more", Highlighted: true},
+			{Title: "Range functions", URL: "https://go.dev/blog/range-functions"},
+		},
+	}},
+	Total: 2,
+}
+
+func render(t *testing.T) digest.Message {
+	t.Helper()
+	tmpl, err := digest.LoadTemplates("", "")
+	if err != nil {
+		t.Fatalf("Expected default templates to load, got %v", err)
+	}
+	msg, err := tmpl.Render(testDigest, "Gator <gator@example.com>", "alice@example.com")
+	if err != nil {
+		t.Fatalf("Expected render to succeed, got %v", err)
+	}
+	return msg
+}
+
+// TestRender checks the subject and both bodies, including HTML escaping.
+func TestRender(t *testing.T) {
+	msg := render(t)
+
+	if msg.Subject != "Your gator digest: 2 new posts" {
+		t.Errorf("Unexpected subject %q", msg.Subject)
+	}
+	for _, want := range []string{"== Go Blog ==", "* Go 1.22 <released>", "https://go.dev/blog/range-functions"} {
+		if !strings.Contains(msg.Text, want) {
+			t.Errorf("Expected text body to contain %q, got:\n%s", want, msg.Text)
+		}
+	}
+	for _, want := range []string{"Go 1.22 &lt;released&gt;", `href="https://go.dev/blog/go1.22"`, "Loop variables &amp; more"} {
+		if !strings.Contains(msg.HTML, want) {
+			t.Errorf("Expected HTML body to contain %q, got:\n%s", want, msg.HTML)
+		}
+	}
+}
+
+// TestMessageBytes checks that the MIME encoding parses back into a text and
+// an HTML alternative.
+func TestMessageBytes(t *testing.T) {
+	msg := render(t)
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(msg.Bytes())))
+	if err != nil {
+		t.Fatalf("Expected a valid message, got %v", err)
+	}
+	if got, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject")); got != msg.Subject {
+		t.Errorf("Expected subject %q, got %q", msg.Subject, got)
+	}
+
+	_, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("Expected a multipart content type, got %v", err)
+	}
+
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	var types []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Expected valid parts, got %v", err)
+		}
+		body, _ := io.ReadAll(part)
+		types = append(types, part.Header.Get("Content-Type"))
+		if strings.HasPrefix(part.Header.Get("Content-Type"), "text/html") && !strings.Contains(string(body), "Range functions") {
+			t.Errorf("Expected decoded HTML part, got %s", body)
+		}
+	}
+	if len(types) != 2 {
+		t.Errorf("Expected text and HTML parts, got %v", types)
+	}
+}
+
+// smtpStub accepts one message and returns the envelope and data.
+func smtpStub(t *testing.T) (string, <-chan []string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+		reply("220 stub ready")
+
+		var lines []string
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch {
+			case cmd == "EHLO" || cmd == "HELO":
+				reply("250 stub")
+			case strings.HasPrefix(strings.ToUpper(line), "MAIL FROM:"), strings.HasPrefix(strings.ToUpper(line), "RCPT TO:"):
+				lines = append(lines, line)
+				reply("250 ok")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				for {
+					data, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if data == ".\r\n" {
+						break
+					}
+					lines = append(lines, strings.TrimRight(data, "\r\n"))
+				}
+				reply("250 queued")
+			case cmd == "QUIT":
+				reply("221 bye")
+				received <- lines
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	return ln.Addr().String(), received
+}
+
+// TestMailerSend delivers a digest to a local SMTP stub.
+func TestMailerSend(t *testing.T) {
+	addr, received := smtpStub(t)
+	host, portStr, _ := net.SplitHostPort(addr)
+	port, _ := strconv.Atoi(portStr)
+
+	msg := render(t)
+	if err := (digest.Mailer{Host: host, Port: port}).Send(msg); err != nil {
+		t.Fatalf("Expected Send to succeed, got %v", err)
+	}
+
+	select {
+	case lines := <-received:
+		all := strings.Join(lines, "\n")
+		for _, want := range []string{"MAIL FROM:<gator@example.com>", "RCPT TO:<alice@example.com>", "Subject: Your gator digest"} {
+			if !strings.Contains(all, want) {
+				t.Errorf("Expected SMTP session to contain %q, got:\n%s", want, all)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the SMTP stub")
+	}
+}
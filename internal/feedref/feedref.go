@@ -0,0 +1,157 @@
+// Package feedref resolves the ways feeds are referred to on the command
+// line: by URL, by a prefix of their ID as shown in listings, or by name.
+package feedref
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// minIDPrefix is the shortest ID prefix tried before falling back to names,
+// so short words are not mistaken for IDs.
+const minIDPrefix = 4
+
+// Feed is a feed that can be referred to.
+type Feed struct {
+	ID   uuid.UUID
+	Name string
+	URL  string
+}
+
+// ErrNotFound is returned when no feed matches a reference.
+var ErrNotFound = errors.New("no feed matches")
+
+// AmbiguousError is returned when a reference matches several feeds equally
+// well.
+type AmbiguousError struct {
+	Ref     string
+	Matches []Feed
+}
+
+func (e *AmbiguousError) Error() string {
+	return fmt.Sprintf("%q matches %d feeds", e.Ref, len(e.Matches))
+}
+
+// IsURL reports whether ref is an absolute http or https URL. URLs only
+// ever match exactly.
+func IsURL(ref string) bool {
+	u, err := url.Parse(ref)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Resolve finds the feed ref refers to among feeds. A URL must match
+// exactly. Otherwise ref is tried as an ID prefix and then as a name, best
+// match first: the whole name, the start of it, a part of it, and finally
+// its letters in order ("gblog" for "Go Blog"). Names are compared without
+// regard to case.
+func Resolve(ref string, feeds []Feed) (Feed, error) {
+	return resolve(ref, feeds, false)
+}
+
+// ResolveExact is Resolve without the partial name matches, for commands
+// that change or delete the feed: a name must match whole, ignoring case.
+func ResolveExact(ref string, feeds []Feed) (Feed, error) {
+	return resolve(ref, feeds, true)
+}
+
+func resolve(ref string, feeds []Feed, exact bool) (Feed, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return Feed{}, ErrNotFound
+	}
+
+	if IsURL(ref) {
+		for _, feed := range feeds {
+			if feed.URL == ref {
+				return feed, nil
+			}
+		}
+		return Feed{}, ErrNotFound
+	}
+
+	if isIDPrefix(ref) {
+		prefix := strings.ToLower(ref)
+		matches := filter(feeds, func(feed Feed) bool {
+			return strings.HasPrefix(feed.ID.String(), prefix)
+		})
+		if len(matches) > 0 {
+			return pick(ref, matches)
+		}
+	}
+
+	name := strings.ToLower(ref)
+	tiers := []func(string) bool{
+		func(s string) bool { return s == name },
+		func(s string) bool { return strings.HasPrefix(s, name) },
+		func(s string) bool { return strings.Contains(s, name) },
+		func(s string) bool { return isSubsequence(name, s) },
+	}
+	if exact {
+		tiers = tiers[:1]
+	}
+	for _, match := range tiers {
+		matches := filter(feeds, func(feed Feed) bool {
+			return match(strings.ToLower(feed.Name))
+		})
+		if len(matches) > 0 {
+			return pick(ref, matches)
+		}
+	}
+
+	return Feed{}, ErrNotFound
+}
+
+func pick(ref string, matches []Feed) (Feed, error) {
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+	return Feed{}, &AmbiguousError{Ref: ref, Matches: matches}
+}
+
+func filter(feeds []Feed, keep func(Feed) bool) []Feed {
+	var matches []Feed
+	for _, feed := range feeds {
+		if keep(feed) {
+			matches = append(matches, feed)
+		}
+	}
+	return matches
+}
+
+// isIDPrefix reports whether ref could be the start of a UUID.
+func isIDPrefix(ref string) bool {
+	if len(ref) < minIDPrefix || len(ref) > 36 {
+		return false
+	}
+	for _, r := range ref {
+		switch {
+		case r >= '0' && r <= '9', r >= 'a' && r <= 'f', r >= 'A' && r <= 'F', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isSubsequence reports whether the letters and digits of needle appear in
+// haystack in order.
+func isSubsequence(needle, haystack string) bool {
+	rest := haystack
+	found := false
+	for _, r := range needle {
+		if r == ' ' {
+			continue
+		}
+		i := strings.IndexRune(rest, r)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(string(r)):]
+		found = true
+	}
+	return found
+}
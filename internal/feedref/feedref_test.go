@@ -0,0 +1,137 @@
+package feedref_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/eefret/gator/internal/feedref"
+	"github.com/google/uuid"
+)
+
+var (
+	goBlog = feedref.Feed{
+		ID:   uuid.MustParse("1a2b3c4d-0000-4000-8000-000000000001"),
+		Name: "Go Blog",
+		URL:  "https://go.dev/blog/feed.atom",
+	}
+	goWeekly = feedref.Feed{
+		ID:   uuid.MustParse("1a2b9999-0000-4000-8000-000000000002"),
+		Name: "Golang Weekly",
+		URL:  "https://golangweekly.com/rss",
+	}
+	hackerNews = feedref.Feed{
+		ID:   uuid.MustParse("cafe0000-0000-4000-8000-000000000003"),
+		Name: "Hacker News",
+		URL:  "https://news.ycombinator.com/rss",
+	}
+	feeds = []feedref.Feed{goBlog, goWeekly, hackerNews}
+)
+
+// TestResolve checks each kind of reference and its precedence.
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want feedref.Feed
+	}{
+		{"https://go.dev/blog/feed.atom", goBlog},
+		{"1a2b3c", goBlog},
+		{"CAFE0000", hackerNews},
+		{"go blog", goBlog},
+		{"golang", goWeekly},
+		{"hacker", hackerNews},
+		{"news", hackerNews},
+		{"gweekly", goWeekly},
+		{"  Hacker News  ", hackerNews},
+	}
+	for _, tt := range tests {
+		got, err := feedref.Resolve(tt.ref, feeds)
+		if err != nil {
+			t.Errorf("Resolve(%q): expected %s, got error %v", tt.ref, tt.want.Name, err)
+			continue
+		}
+		if got.ID != tt.want.ID {
+			t.Errorf("Resolve(%q): expected %s, got %s", tt.ref, tt.want.Name, got.Name)
+		}
+	}
+}
+
+// TestResolveExactNameWins checks that an exact name is not ambiguous with
+// longer names starting the same way.
+func TestResolveExactNameWins(t *testing.T) {
+	gopher := feedref.Feed{ID: uuid.New(), Name: "Go", URL: "https://example.com/go"}
+	got, err := feedref.Resolve("go", append([]feedref.Feed{gopher}, feeds...))
+	if err != nil {
+		t.Fatalf("Expected a match, got %v", err)
+	}
+	if got.ID != gopher.ID {
+		t.Errorf("Expected Go, got %s", got.Name)
+	}
+}
+
+// TestResolveExact checks that only URLs, ID prefixes and whole names
+// resolve when partial matches are not allowed.
+func TestResolveExact(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want feedref.Feed
+	}{
+		{"https://go.dev/blog/feed.atom", goBlog},
+		{"1a2b3c", goBlog},
+		{"go blog", goBlog},
+		{"HACKER NEWS", hackerNews},
+	}
+	for _, tt := range tests {
+		got, err := feedref.ResolveExact(tt.ref, feeds)
+		if err != nil {
+			t.Errorf("ResolveExact(%q): expected %s, got error %v", tt.ref, tt.want.Name, err)
+			continue
+		}
+		if got.ID != tt.want.ID {
+			t.Errorf("ResolveExact(%q): expected %s, got %s", tt.ref, tt.want.Name, got.Name)
+		}
+	}
+
+	for _, ref := range []string{"golang", "news", "gblog"} {
+		if _, err := feedref.ResolveExact(ref, feeds); !errors.Is(err, feedref.ErrNotFound) {
+			t.Errorf("ResolveExact(%q): expected ErrNotFound, got %v", ref, err)
+		}
+	}
+}
+
+// TestResolveAmbiguous checks that every equally good match is reported.
+func TestResolveAmbiguous(t *testing.T) {
+	for _, ref := range []string{"1a2b", "go"} {
+		_, err := feedref.Resolve(ref, feeds)
+		var ambiguous *feedref.AmbiguousError
+		if !errors.As(err, &ambiguous) {
+			t.Errorf("Resolve(%q): expected an AmbiguousError, got %v", ref, err)
+			continue
+		}
+		if len(ambiguous.Matches) != 2 {
+			t.Errorf("Resolve(%q): expected 2 matches, got %d", ref, len(ambiguous.Matches))
+		}
+	}
+}
+
+// TestResolveNotFound checks references that match nothing, including URLs,
+// which are never matched partially.
+func TestResolveNotFound(t *testing.T) {
+	for _, ref := range []string{"", "https://go.dev/blog", "rust", "zzzz"} {
+		if _, err := feedref.Resolve(ref, feeds); !errors.Is(err, feedref.ErrNotFound) {
+			t.Errorf("Resolve(%q): expected ErrNotFound, got %v", ref, err)
+		}
+	}
+}
+
+// TestResolveHexName checks that a name that looks like an ID is still
+// found by name when no ID starts with it.
+func TestResolveHexName(t *testing.T) {
+	cafe := feedref.Feed{ID: uuid.MustParse("00000000-0000-4000-8000-000000000004"), Name: "Decaf Beef"}
+	got, err := feedref.Resolve("beef", []feedref.Feed{cafe, goBlog})
+	if err != nil {
+		t.Fatalf("Expected a match, got %v", err)
+	}
+	if got.ID != cafe.ID {
+		t.Errorf("Expected Decaf Beef, got %s", got.Name)
+	}
+}
@@ -0,0 +1,166 @@
+// Package filter matches posts against user-defined rules that hide,
+// highlight, mark read or save them.
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Field is the part of a post a rule looks at.
+type Field string
+
+const (
+	FieldTitle       Field = "title"
+	FieldDescription Field = "description"
+	FieldAuthor      Field = "author"
+	FieldCategory    Field = "category"
+	// FieldAny matches any of the above.
+	FieldAny Field = "any"
+)
+
+// Action is what happens to matching posts.
+type Action string
+
+const (
+	ActionHide      Action = "hide"
+	ActionMarkRead  Action = "mark-read"
+	ActionHighlight Action = "highlight"
+	ActionSave      Action = "save"
+)
+
+// ParseField validates a field name.
+func ParseField(name string) (Field, error) {
+	switch f := Field(strings.ToLower(name)); f {
+	case FieldTitle, FieldDescription, FieldAuthor, FieldCategory, FieldAny:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown field %q (want title, description, author, category or any)", name)
+}
+
+// ParseAction validates an action name.
+func ParseAction(name string) (Action, error) {
+	switch a := Action(strings.ToLower(name)); a {
+	case ActionHide, ActionMarkRead, ActionHighlight, ActionSave:
+		return a, nil
+	}
+	return "", fmt.Errorf("unknown action %q (want hide, mark-read, highlight or save)", name)
+}
+
+// Rule is a stored filter rule. Pattern is a Go regular expression when
+// Regex is set, otherwise a comma-separated list of keywords matched as
+// whole words, ignoring case. A rule applies to every followed feed unless
+// scoped to FeedID or Tag.
+type Rule struct {
+	ID      uuid.UUID
+	Field   Field
+	Pattern string
+	Regex   bool
+	Action  Action
+	FeedID  uuid.UUID
+	Tag     string
+}
+
+// Post is the text of a post that rules are matched against. Description
+// should already be plain text.
+type Post struct {
+	FeedID      uuid.UUID
+	Title       string
+	Description string
+	Author      string
+	Categories  []string
+}
+
+// Matcher is a compiled Rule.
+type Matcher struct {
+	Rule
+	re *regexp.Regexp
+}
+
+// Compile validates rule and prepares it for matching.
+func Compile(rule Rule) (*Matcher, error) {
+	expr := rule.Pattern
+	if !rule.Regex {
+		var words []string
+		for _, keyword := range strings.Split(rule.Pattern, ",") {
+			if keyword = strings.TrimSpace(keyword); keyword != "" {
+				words = append(words, regexp.QuoteMeta(keyword))
+			}
+		}
+		if len(words) == 0 {
+			return nil, fmt.Errorf("no keywords in %q", rule.Pattern)
+		}
+		// \b would not match around keywords such as "C++" that end in
+		// punctuation, so look for any non-word neighbour instead.
+		expr = `(?i)(?:^|[^\pL\pN_])(?:` + strings.Join(words, "|") + `)(?:$|[^\pL\pN_])`
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %v", rule.Pattern, err)
+	}
+	return &Matcher{Rule: rule, re: re}, nil
+}
+
+// Match reports whether post, from a feed carrying feedTags, matches.
+func (m *Matcher) Match(post Post, feedTags []string) bool {
+	if m.FeedID != uuid.Nil && m.FeedID != post.FeedID {
+		return false
+	}
+	if m.Tag != "" && !slices.Contains(feedTags, m.Tag) {
+		return false
+	}
+
+	switch m.Field {
+	case FieldTitle:
+		return m.re.MatchString(post.Title)
+	case FieldDescription:
+		return m.re.MatchString(post.Description)
+	case FieldAuthor:
+		return m.re.MatchString(post.Author)
+	case FieldCategory:
+		return slices.ContainsFunc(post.Categories, m.re.MatchString)
+	case FieldAny:
+		return m.re.MatchString(post.Title) ||
+			m.re.MatchString(post.Description) ||
+			m.re.MatchString(post.Author) ||
+			slices.ContainsFunc(post.Categories, m.re.MatchString)
+	}
+	return false
+}
+
+// Result is the combined effect of every matching rule on a post.
+type Result struct {
+	Hide      bool
+	MarkRead  bool
+	Highlight bool
+	Save      bool
+}
+
+// Set is a user's compiled rules.
+type Set []*Matcher
+
+// Evaluate applies every rule in the set to post.
+func (s Set) Evaluate(post Post, feedTags []string) Result {
+	var result Result
+	for _, m := range s {
+		if !m.Match(post, feedTags) {
+			continue
+		}
+		switch m.Action {
+		case ActionHide:
+			result.Hide = true
+		case ActionMarkRead:
+			result.MarkRead = true
+		case ActionHighlight:
+			result.Highlight = true
+		case ActionSave:
+			result.Save = true
+		}
+	}
+	return result
+}
@@ -0,0 +1,121 @@
+package filter_test
+
+import (
+	"testing"
+
+	"github.com/eefret/gator/internal/filter"
+	"github.com/google/uuid"
+)
+
+func compile(t *testing.T, rule filter.Rule) *filter.Matcher {
+	t.Helper()
+	m, err := filter.Compile(rule)
+	if err != nil {
+		t.Fatalf("Expected rule to compile, got %v", err)
+	}
+	return m
+}
+
+// TestKeywords checks whole-word, case-insensitive keyword matching.
+func TestKeywords(t *testing.T) {
+	m := compile(t, filter.Rule{Field: filter.FieldTitle, Pattern: "sponsored, C++"})
+
+	cases := []struct {
+		title string
+		want  bool
+	}{
+		{"Sponsored: buy now", true},
+		{"New in C++ 26", true},
+		{"Unsponsored thoughts", false},
+		{"C# tips", false},
+	}
+
+	for _, tc := range cases {
+		if got := m.Match(filter.Post{Title: tc.title}, nil); got != tc.want {
+			t.Errorf("%q: expected %v, got %v", tc.title, tc.want, got)
+		}
+	}
+}
+
+// TestRegexFields checks regular expressions against each field.
+func TestRegexFields(t *testing.T) {
+	post := filter.Post{
+		Title:       "Weekly roundup",
+		Description: "Patched CVE-2024-1234 today",
+		Author:      "Jane Doe",
+		Categories:  []string{"Go", "Security"},
+	}
+
+	cases := []struct {
+		field   filter.Field
+		pattern string
+		want    bool
+	}{
+		{filter.FieldDescription, `CVE-\d{4}-\d+`, true},
+		{filter.FieldTitle, `CVE-\d{4}-\d+`, false},
+		{filter.FieldAuthor, `^Jane`, true},
+		{filter.FieldCategory, `^Security$`, true},
+		{filter.FieldCategory, `^Sec$`, false},
+		{filter.FieldAny, `Doe`, true},
+	}
+
+	for _, tc := range cases {
+		m := compile(t, filter.Rule{Field: tc.field, Pattern: tc.pattern, Regex: true})
+		if got := m.Match(post, nil); got != tc.want {
+			t.Errorf("%s %q: expected %v, got %v", tc.field, tc.pattern, tc.want, got)
+		}
+	}
+}
+
+// TestScope checks that rules scoped to a feed or tag leave other posts alone.
+func TestScope(t *testing.T) {
+	feedA, feedB := uuid.New(), uuid.New()
+
+	byFeed := compile(t, filter.Rule{Field: filter.FieldAny, Pattern: "go", FeedID: feedA})
+	if !byFeed.Match(filter.Post{FeedID: feedA, Title: "Go"}, nil) {
+		t.Errorf("Expected feed-scoped rule to match its feed")
+	}
+	if byFeed.Match(filter.Post{FeedID: feedB, Title: "Go"}, nil) {
+		t.Errorf("Expected feed-scoped rule to skip other feeds")
+	}
+
+	byTag := compile(t, filter.Rule{Field: filter.FieldAny, Pattern: "go", Tag: "lang"})
+	if !byTag.Match(filter.Post{Title: "Go"}, []string{"news", "lang"}) {
+		t.Errorf("Expected tag-scoped rule to match tagged feeds")
+	}
+	if byTag.Match(filter.Post{Title: "Go"}, []string{"news"}) {
+		t.Errorf("Expected tag-scoped rule to skip untagged feeds")
+	}
+}
+
+// TestEvaluate checks that the actions of all matching rules combine.
+func TestEvaluate(t *testing.T) {
+	set := filter.Set{
+		compile(t, filter.Rule{Field: filter.FieldTitle, Pattern: "ad", Action: filter.ActionHide}),
+		compile(t, filter.Rule{Field: filter.FieldTitle, Pattern: "release", Action: filter.ActionHighlight}),
+		compile(t, filter.Rule{Field: filter.FieldTitle, Pattern: "release", Action: filter.ActionSave}),
+		compile(t, filter.Rule{Field: filter.FieldAuthor, Pattern: "bot", Action: filter.ActionMarkRead}),
+	}
+
+	got := set.Evaluate(filter.Post{Title: "Release 1.0", Author: "bot"}, nil)
+	want := filter.Result{Highlight: true, Save: true, MarkRead: true}
+	if got != want {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+}
+
+// TestCompileErrors checks that bad patterns and names are rejected.
+func TestCompileErrors(t *testing.T) {
+	if _, err := filter.Compile(filter.Rule{Pattern: "(", Regex: true}); err == nil {
+		t.Errorf("Expected invalid regex to fail")
+	}
+	if _, err := filter.Compile(filter.Rule{Pattern: " , "}); err == nil {
+		t.Errorf("Expected empty keyword list to fail")
+	}
+	if _, err := filter.ParseField("body"); err == nil {
+		t.Errorf("Expected unknown field to fail")
+	}
+	if _, err := filter.ParseAction("delete"); err == nil {
+		t.Errorf("Expected unknown action to fail")
+	}
+}
@@ -0,0 +1,84 @@
+package htmltext_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eefret/gator/internal/htmltext"
+)
+
+// TestSanitize checks that only allowlisted markup survives.
+func TestSanitize(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain text", "Just text", "Just text"},
+		{"script removed with content", `<p>Hi<script>alert("x")</script></p>`, "<p>Hi</p>"},
+		{"event handlers stripped", `<p onclick="evil()" class="x">Hi</p>`, "<p>Hi</p>"},
+		{"javascript links neutralized", `<a href="javascript:evil()">click</a>`, "<a>click</a>"},
+		{"safe links kept", `<a href="https://example.com/" target="_blank">x</a>`, `<a href="https://example.com/">x</a>`},
+		{"tracking pixel dropped", `<p>Hi<img src="https://t.example.com/p.gif" width="1" height="1"></p>`, "<p>Hi</p>"},
+		{"images kept", `<img src="https://example.com/a.png" alt="A" style="x">`, `<img src="https://example.com/a.png" alt="A">`},
+		{"unknown tags unwrapped", `<div><span style="color:red">red</span></div>`, "red"},
+		{"text re-escaped", `1 &lt; 2 &amp; 3`, "1 &lt; 2 &amp; 3"},
+		{"iframes dropped", `<iframe src="https://ads.example.com/"></iframe>after`, "after"},
+	}
+
+	for _, tc := range cases {
+		if got := htmltext.Sanitize(tc.in); got != tc.want {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
+
+// TestRender checks paragraphs, lists, quotes and link footnotes.
+func TestRender(t *testing.T) {
+	in := `<p>Hello <b>world</b>, see <a href="https://example.com/a">this post</a>.</p>
+<ul><li>one</li><li>two <a href="https://example.com/a">again</a></li></ul>
+<blockquote>quoted text</blockquote>
+<script>ignored()</script>
+<p>Bare <a href="https://example.com/b">https://example.com/b</a></p>`
+
+	want := `Hello world, see this post[1].
+
+• one
+• two again[1]
+
+> quoted text
+
+Bare https://example.com/b
+
+[1] https://example.com/a`
+
+	if got := htmltext.Render(in, 80); got != want {
+		t.Errorf("Unexpected rendering:\n%s\n--- want ---\n%s", got, want)
+	}
+}
+
+// TestRenderWraps checks that long paragraphs wrap at the requested width
+// and list items keep a hanging indent.
+func TestRenderWraps(t *testing.T) {
+	got := htmltext.Render(`<ol><li>alpha beta gamma delta epsilon</li></ol>`, 16)
+	want := "1. alpha beta\n   gamma delta\n   epsilon"
+	if got != want {
+		t.Errorf("Unexpected wrapping:\n%q\n--- want ---\n%q", got, want)
+	}
+
+	for _, line := range strings.Split(htmltext.Render(strings.Repeat("word ", 50), 20), "\n") {
+		if len(line) > 20 {
+			t.Errorf("Line longer than width: %q", line)
+		}
+	}
+}
+
+// TestRenderPlainText checks that descriptions without markup keep their
+// paragraph breaks and have entities decoded.
+func TestRenderPlainText(t *testing.T) {
+	got := htmltext.Render("First &amp; foremost.\n\nSecond   paragraph.", 80)
+	want := "First & foremost.\n\nSecond paragraph."
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,345 @@
+package htmltext
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// block is a paragraph, list item or line of rendered text.
+type block struct {
+	// prefix is printed before the first line, e.g. "• " or "> ".
+	prefix string
+	// indent is printed before continuation lines.
+	indent string
+	text   strings.Builder
+	pre    bool
+	// gap is set when a blank line should separate this block from the
+	// previous one.
+	gap bool
+}
+
+type list struct {
+	ordered bool
+	n       int
+}
+
+type renderer struct {
+	blocks []*block
+	links  []string
+	// hrefs holds the targets of the open <a> elements and the length of
+	// the current block's text when each was opened.
+	hrefs      []string
+	lists      []list
+	quoteDepth int
+	preDepth   int
+	skipDepth  int
+	skipTag    atom.Atom
+}
+
+// Render converts an HTML fragment to plain text wrapped at width columns.
+// Paragraphs are separated by blank lines, list items are bulleted, quotes
+// are prefixed with "> " and links are replaced by numbered footnotes listed
+// at the end.
+func Render(s string, width int) string {
+	r := &renderer{}
+
+	if !strings.Contains(s, "<") {
+		// Plain text: keep the author's paragraph breaks.
+		for _, para := range strings.Split(html.UnescapeString(s), "\n\n") {
+			r.startBlock("", "", true)
+			r.text(para)
+		}
+		return r.String(width)
+	}
+
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		tok := z.Token()
+
+		if r.skipDepth > 0 {
+			switch {
+			case tt == html.StartTagToken && tok.DataAtom == r.skipTag:
+				r.skipDepth++
+			case tt == html.EndTagToken && tok.DataAtom == r.skipTag:
+				r.skipDepth--
+			}
+			continue
+		}
+
+		switch tt {
+		case html.TextToken:
+			r.text(tok.Data)
+		case html.StartTagToken, html.SelfClosingTagToken:
+			r.start(tok, tt == html.SelfClosingTagToken)
+		case html.EndTagToken:
+			r.end(tok)
+		}
+	}
+
+	return r.String(width)
+}
+
+func (r *renderer) current() *block {
+	if len(r.blocks) == 0 {
+		r.startBlock("", "", true)
+	}
+	return r.blocks[len(r.blocks)-1]
+}
+
+// startBlock begins a new block unless the current one is still empty, in
+// which case it is reused with the new prefix.
+func (r *renderer) startBlock(prefix, indent string, gap bool) {
+	quote := strings.Repeat("> ", r.quoteDepth)
+	prefix = quote + prefix
+	indent = quote + indent
+
+	if n := len(r.blocks); n > 0 && strings.TrimSpace(r.blocks[n-1].text.String()) == "" {
+		b := r.blocks[n-1]
+		b.prefix, b.indent, b.pre = prefix, indent, r.preDepth > 0
+		b.gap = b.gap || gap
+		return
+	}
+
+	r.blocks = append(r.blocks, &block{prefix: prefix, indent: indent, pre: r.preDepth > 0, gap: gap})
+}
+
+func (r *renderer) text(s string) {
+	b := r.current()
+	if r.preDepth > 0 {
+		b.text.WriteString(s)
+		return
+	}
+
+	// Collapse runs of whitespace as a browser would.
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		if s != "" && b.text.Len() > 0 {
+			b.text.WriteByte(' ')
+		}
+		return
+	}
+
+	cur := b.text.String()
+	if startsWithSpace(s) && cur != "" && !strings.HasSuffix(cur, " ") {
+		b.text.WriteByte(' ')
+	}
+	b.text.WriteString(strings.Join(fields, " "))
+	if endsWithSpace(s) {
+		b.text.WriteByte(' ')
+	}
+}
+
+func (r *renderer) start(tok html.Token, selfClosing bool) {
+	switch tok.DataAtom {
+	case atom.Script, atom.Style, atom.Iframe, atom.Object, atom.Noscript, atom.Template, atom.Svg, atom.Head, atom.Title:
+		if !selfClosing {
+			r.skipDepth = 1
+			r.skipTag = tok.DataAtom
+		}
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer,
+		atom.Figure, atom.Figcaption, atom.Table, atom.Tr, atom.Dl, atom.Dt, atom.Dd,
+		atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		r.startBlock("", "", true)
+	case atom.Br:
+		r.startBlock("", "", false)
+	case atom.Hr:
+		r.startBlock("", "", true)
+		r.current().text.WriteString("----")
+		r.startBlock("", "", true)
+	case atom.Blockquote:
+		r.quoteDepth++
+		r.startBlock("", "", true)
+	case atom.Pre:
+		r.preDepth++
+		r.startBlock("", "", true)
+	case atom.Ul, atom.Ol:
+		r.lists = append(r.lists, list{ordered: tok.DataAtom == atom.Ol})
+		r.startBlock("", "", len(r.lists) == 1)
+	case atom.Li:
+		depth := len(r.lists)
+		if depth == 0 {
+			depth = 1
+			r.lists = append(r.lists, list{})
+		}
+		l := &r.lists[depth-1]
+		l.n++
+
+		marker := "• "
+		if l.ordered {
+			marker = strconv.Itoa(l.n) + ". "
+		}
+		pad := strings.Repeat("  ", depth-1)
+		r.startBlock(pad+marker, pad+strings.Repeat(" ", utf8.RuneCountInString(marker)), false)
+	case atom.A:
+		r.hrefs = append(r.hrefs, strings.TrimSpace(attr(tok, "href")))
+		if selfClosing {
+			r.closeLink()
+		}
+	case atom.Img:
+		if alt := strings.TrimSpace(attr(tok, "alt")); alt != "" {
+			r.text(" [image: " + alt + "] ")
+		}
+	case atom.Td, atom.Th:
+		r.text(" ")
+	}
+}
+
+func (r *renderer) end(tok html.Token) {
+	switch tok.DataAtom {
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer,
+		atom.Figure, atom.Figcaption, atom.Table, atom.Tr, atom.Dl,
+		atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		r.startBlock("", "", true)
+	case atom.Blockquote:
+		if r.quoteDepth > 0 {
+			r.quoteDepth--
+		}
+		r.startBlock("", "", true)
+	case atom.Pre:
+		if r.preDepth > 0 {
+			r.preDepth--
+		}
+		r.startBlock("", "", true)
+	case atom.Ul, atom.Ol:
+		if len(r.lists) > 0 {
+			r.lists = r.lists[:len(r.lists)-1]
+		}
+		r.startBlock("", "", len(r.lists) == 0)
+	case atom.Li:
+		r.startBlock("", "", false)
+	case atom.A:
+		r.closeLink()
+	}
+}
+
+// closeLink adds a footnote marker for the innermost open link, unless the
+// link text already is the URL.
+func (r *renderer) closeLink() {
+	if len(r.hrefs) == 0 {
+		return
+	}
+	href := r.hrefs[len(r.hrefs)-1]
+	r.hrefs = r.hrefs[:len(r.hrefs)-1]
+
+	if href == "" || strings.HasPrefix(href, "#") {
+		return
+	}
+
+	b := r.current()
+	if strings.HasSuffix(strings.TrimSpace(b.text.String()), href) {
+		return
+	}
+
+	n := len(r.links) + 1
+	for i, link := range r.links {
+		if link == href {
+			n = i + 1
+			break
+		}
+	}
+	if n > len(r.links) {
+		r.links = append(r.links, href)
+	}
+
+	text := b.text.String()
+	trimmed := strings.TrimRight(text, " ")
+	b.text.Reset()
+	fmt.Fprintf(&b.text, "%s[%d]", trimmed, n)
+	if len(trimmed) < len(text) {
+		b.text.WriteByte(' ')
+	}
+}
+
+// String lays out the blocks and footnotes.
+func (r *renderer) String(width int) string {
+	var out []string
+	for _, b := range r.blocks {
+		text := b.text.String()
+		if !b.pre {
+			text = strings.TrimSpace(text)
+		} else {
+			text = strings.Trim(text, "\n")
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		if len(out) > 0 && b.gap {
+			out = append(out, "")
+		}
+
+		if b.pre {
+			for _, line := range strings.Split(text, "\n") {
+				out = append(out, b.indent+line)
+			}
+			continue
+		}
+		out = append(out, wrap(text, width, b.prefix, b.indent)...)
+	}
+
+	if len(r.links) > 0 {
+		if len(out) > 0 {
+			out = append(out, "")
+		}
+		for i, link := range r.links {
+			out = append(out, fmt.Sprintf("[%d] %s", i+1, link))
+		}
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// wrap breaks text into lines of at most width columns, starting the first
+// with prefix and the rest with indent. Words longer than a line, such as
+// URLs, are left intact.
+func wrap(text string, width int, prefix, indent string) []string {
+	var lines []string
+	line := prefix
+	lineLen := utf8.RuneCountInString(prefix)
+	empty := true
+
+	for _, word := range strings.Fields(text) {
+		wordLen := utf8.RuneCountInString(word)
+		if !empty && width > 0 && lineLen+1+wordLen > width {
+			lines = append(lines, line)
+			line = indent
+			lineLen = utf8.RuneCountInString(indent)
+			empty = true
+		}
+		if !empty {
+			line += " "
+			lineLen++
+		}
+		line += word
+		lineLen += wordLen
+		empty = false
+	}
+
+	return append(lines, line)
+}
+
+func attr(tok html.Token, key string) string {
+	for _, a := range tok.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func startsWithSpace(s string) bool {
+	return s != "" && strings.ContainsRune(" \t\n\r\f", rune(s[0]))
+}
+
+func endsWithSpace(s string) bool {
+	return s != "" && strings.ContainsRune(" \t\n\r\f", rune(s[len(s)-1]))
+}
@@ -0,0 +1,193 @@
+// Package htmltext cleans up the HTML found in feed descriptions: Sanitize
+// reduces it to a safe allowlist before it is stored, and Render turns it
+// into wrapped plain text for the terminal.
+package htmltext
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowedAttrs lists the tags kept by Sanitize and the attributes each may
+// carry. Other tags are unwrapped, keeping their text.
+var allowedAttrs = map[atom.Atom][]string{
+	atom.A:          {"href", "title"},
+	atom.B:          nil,
+	atom.Blockquote: nil,
+	atom.Br:         nil,
+	atom.Code:       nil,
+	atom.Em:         nil,
+	atom.H1:         nil,
+	atom.H2:         nil,
+	atom.H3:         nil,
+	atom.H4:         nil,
+	atom.H5:         nil,
+	atom.H6:         nil,
+	atom.Hr:         nil,
+	atom.I:          nil,
+	atom.Img:        {"src", "alt", "title"},
+	atom.Li:         nil,
+	atom.Ol:         nil,
+	atom.P:          nil,
+	atom.Pre:        nil,
+	atom.Strong:     nil,
+	atom.Ul:         nil,
+}
+
+// droppedTags are removed together with everything inside them.
+var droppedTags = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+	atom.Embed:    true,
+	atom.Noscript: true,
+	atom.Form:     true,
+	atom.Template: true,
+	atom.Svg:      true,
+	atom.Math:     true,
+}
+
+// Sanitize returns s with only allowlisted tags and attributes. Scripts,
+// styles and embeds are removed with their content, links are limited to
+// http, https and mailto, and tracking pixels are dropped.
+func Sanitize(s string) string {
+	if !strings.ContainsAny(s, "<&") {
+		return s
+	}
+
+	var b strings.Builder
+	z := html.NewTokenizer(strings.NewReader(s))
+	skipDepth := 0
+	var skipTag atom.Atom
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		tok := z.Token()
+
+		if skipDepth > 0 {
+			switch {
+			case tt == html.StartTagToken && tok.DataAtom == skipTag:
+				skipDepth++
+			case tt == html.EndTagToken && tok.DataAtom == skipTag:
+				skipDepth--
+			}
+			continue
+		}
+
+		switch tt {
+		case html.TextToken:
+			b.WriteString(html.EscapeString(tok.Data))
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if droppedTags[tok.DataAtom] {
+				if tt == html.StartTagToken && !isVoid(tok.DataAtom) {
+					skipDepth = 1
+					skipTag = tok.DataAtom
+				}
+				continue
+			}
+			if clean, ok := cleanTag(tok); ok {
+				b.WriteString(clean.String())
+			}
+		case html.EndTagToken:
+			if _, ok := allowedAttrs[tok.DataAtom]; ok && !isVoid(tok.DataAtom) {
+				b.WriteString(tok.String())
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// cleanTag strips disallowed attributes from an allowlisted tag. It reports
+// false for tags that should not be emitted at all.
+func cleanTag(tok html.Token) (html.Token, bool) {
+	allowed, ok := allowedAttrs[tok.DataAtom]
+	if !ok {
+		return tok, false
+	}
+
+	var attrs []html.Attribute
+	for _, attr := range tok.Attr {
+		if attr.Namespace != "" || !contains(allowed, attr.Key) {
+			continue
+		}
+		if (attr.Key == "href" || attr.Key == "src") && !safeURL(attr.Val) {
+			continue
+		}
+		attrs = append(attrs, html.Attribute{Key: attr.Key, Val: attr.Val})
+	}
+
+	switch tok.DataAtom {
+	case atom.Img:
+		if isTrackingPixel(tok) || getAttr(attrs, "src") == "" {
+			return tok, false
+		}
+	case atom.A:
+		if getAttr(attrs, "href") == "" {
+			// Keep the element so the end tag stays balanced.
+			attrs = nil
+		}
+	}
+
+	tok.Attr = attrs
+	return tok, true
+}
+
+// isTrackingPixel reports images sized 1x1 or smaller.
+func isTrackingPixel(tok html.Token) bool {
+	for _, key := range []string{"width", "height"} {
+		if v, err := strconv.Atoi(strings.TrimSuffix(getAttr(tok.Attr, key), "px")); err == nil && v <= 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func safeURL(raw string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "mailto":
+		return true
+	case "":
+		// Relative references cannot carry a javascript: scheme.
+		return !strings.Contains(raw, ":")
+	}
+	return false
+}
+
+func isVoid(a atom.Atom) bool {
+	switch a {
+	case atom.Br, atom.Hr, atom.Img, atom.Embed, atom.Input, atom.Meta, atom.Link, atom.Wbr:
+		return true
+	}
+	return false
+}
+
+func getAttr(attrs []html.Attribute, key string) string {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
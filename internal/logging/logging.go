@@ -0,0 +1,59 @@
+// Package logging builds the slog logger used by the commands and the
+// aggregator from the settings in the config file.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Options select the log level, format and destination. The zero value logs
+// info and above as text to stderr.
+type Options struct {
+	// Level is one of debug, info, warn or error.
+	Level string
+	// Format is either text or json.
+	Format string
+	// File is a path to append logs to instead of stderr.
+	File string
+}
+
+// New returns a logger configured from opts and a function that closes the
+// log file, if one was opened.
+func New(opts Options) (*slog.Logger, func() error, error) {
+	var level slog.Level
+	if opts.Level != "" {
+		if err := level.UnmarshalText([]byte(opts.Level)); err != nil {
+			return nil, nil, fmt.Errorf("invalid log level %q: %v", opts.Level, err)
+		}
+	}
+
+	var w io.Writer = os.Stderr
+	closer := func() error { return nil }
+	if opts.File != "" {
+		f, err := os.OpenFile(opts.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening log file: %v", err)
+		}
+		w = f
+		closer = f.Close
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch strings.ToLower(opts.Format) {
+	case "", "text":
+		handler = slog.NewTextHandler(w, handlerOpts)
+	case "json":
+		handler = slog.NewJSONHandler(w, handlerOpts)
+	default:
+		closer()
+		return nil, nil, fmt.Errorf("invalid log format %q (expected text or json)", opts.Format)
+	}
+
+	return slog.New(handler), closer, nil
+}
@@ -0,0 +1,57 @@
+package logging_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eefret/gator/internal/logging"
+)
+
+// TestNewJSONFile checks that JSON logs are appended to the configured file
+// and that records below the configured level are dropped.
+func TestNewJSONFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gator.log")
+
+	logger, closeLog, err := logging.New(logging.Options{Level: "warn", Format: "json", File: path})
+	if err != nil {
+		t.Fatalf("Expected New to succeed, got error: %v", err)
+	}
+
+	logger.Info("dropped")
+	logger.Warn("feed fetch failed", "feed_id", "abc", "status", "timeout")
+
+	if err := closeLog(); err != nil {
+		t.Fatalf("Expected close to succeed, got error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected 1 log line, got %d: %q", len(lines), data)
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("Expected JSON log line, got error: %v", err)
+	}
+	if record["msg"] != "feed fetch failed" || record["feed_id"] != "abc" || record["level"] != "WARN" {
+		t.Errorf("Unexpected log record: %v", record)
+	}
+}
+
+// TestNewInvalidOptions checks that unknown levels and formats are rejected.
+func TestNewInvalidOptions(t *testing.T) {
+	if _, _, err := logging.New(logging.Options{Level: "loud"}); err == nil {
+		t.Error("Expected error for invalid level, got nil")
+	}
+	if _, _, err := logging.New(logging.Options{Format: "xml"}); err == nil {
+		t.Error("Expected error for invalid format, got nil")
+	}
+}
@@ -0,0 +1,54 @@
+package metrics_test
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/eefret/gator/internal/metrics"
+)
+
+// TestExposition checks the rendered text format for each metric type.
+func TestExposition(t *testing.T) {
+	r := metrics.NewRegistry()
+
+	fetches := r.NewCounter("gator_fetches_total", "Feed fetches by status.", "status")
+	fetches.Inc("success")
+	fetches.Inc("success")
+	fetches.Inc("parse_error")
+
+	latency := r.NewHistogram("gator_fetch_duration_seconds", "Fetch latency.", []float64{0.5, 1})
+	latency.Observe(0.25)
+	latency.Observe(0.75)
+	latency.Observe(3)
+
+	r.NewGaugeFunc("gator_queue_lag_seconds", "Queue lag.", func() (float64, error) { return 12.5, nil })
+	r.NewGaugeFunc("gator_broken", "Always fails.", func() (float64, error) { return 0, errors.New("boom") })
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	want := `# HELP gator_fetches_total Feed fetches by status.
+# TYPE gator_fetches_total counter
+gator_fetches_total{status="parse_error"} 1
+gator_fetches_total{status="success"} 2
+# HELP gator_fetch_duration_seconds Fetch latency.
+# TYPE gator_fetch_duration_seconds histogram
+gator_fetch_duration_seconds_bucket{le="0.5"} 1
+gator_fetch_duration_seconds_bucket{le="1"} 2
+gator_fetch_duration_seconds_bucket{le="+Inf"} 3
+gator_fetch_duration_seconds_sum 4
+gator_fetch_duration_seconds_count 3
+# HELP gator_queue_lag_seconds Queue lag.
+# TYPE gator_queue_lag_seconds gauge
+gator_queue_lag_seconds 12.5
+`
+	if got := rec.Body.String(); got != want {
+		t.Errorf("Unexpected exposition:\n%s", got)
+	}
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Expected text/plain content type, got %q", ct)
+	}
+}
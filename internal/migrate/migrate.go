@@ -0,0 +1,306 @@
+// Package migrate applies the SQL migrations in sql/schema. It reads the
+// goose file format and keeps goose's goose_db_version table, so databases
+// set up with the goose CLI carry on where they left off.
+package migrate
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoApplied is returned by Down when no migration has been applied.
+var ErrNoApplied = errors.New("no migrations have been applied")
+
+// Migration is one numbered schema change.
+type Migration struct {
+	Version int64
+	// Name is the file name, e.g. 001_users.sql.
+	Name string
+	Up   string
+	Down string
+}
+
+// Status is a migration and whether it has been applied.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Load reads the migrations in the top directory of fsys. Files are named
+// <version>_<description>.sql and hold a "-- +goose Up" section, optionally
+// followed by "-- +goose Down". Migrations are returned in version order.
+func Load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	seen := make(map[int64]string)
+	for _, name := range names {
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("%s: name must start with a version and an underscore", name)
+		}
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("%s: invalid version %q", name, prefix)
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("%s: version %d is also used by %s", name, version, other)
+		}
+		seen[version] = name
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		up, down, err := parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		migrations = append(migrations, Migration{Version: version, Name: path.Base(name), Up: up, Down: down})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// parse splits a migration file into its up and down SQL. Statement
+// annotations are accepted but not needed: each section runs as one batch.
+func parse(data string) (up, down string, err error) {
+	var upLines, downLines []string
+	var section *[]string
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		annotation, ok := strings.CutPrefix(strings.TrimSpace(line), "-- +goose ")
+		if !ok {
+			if section != nil {
+				*section = append(*section, line)
+			}
+			continue
+		}
+
+		switch strings.TrimSpace(annotation) {
+		case "Up":
+			if section != nil {
+				return "", "", fmt.Errorf("Up must come first and only once")
+			}
+			section = &upLines
+		case "Down":
+			if section != &upLines {
+				return "", "", fmt.Errorf("Down must follow Up and appear only once")
+			}
+			section = &downLines
+		case "StatementBegin", "StatementEnd":
+		default:
+			return "", "", fmt.Errorf("unsupported annotation %q", line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", err
+	}
+
+	up = strings.TrimSpace(strings.Join(upLines, "\n"))
+	if up == "" {
+		return "", "", fmt.Errorf("missing -- +goose Up section")
+	}
+	return up, strings.TrimSpace(strings.Join(downLines, "\n")), nil
+}
+
+// Dialect adapts the migrations, which are written for PostgreSQL, to the
+// database engine they run on.
+type Dialect struct {
+	// VersionTable creates goose_db_version if it does not exist.
+	VersionTable string
+	// Rewrite, if set, translates each section of a migration before it
+	// runs.
+	Rewrite func(sql string) string
+}
+
+// Postgres is the dialect the migrations are written in.
+var Postgres = Dialect{
+	VersionTable: `CREATE TABLE IF NOT EXISTS goose_db_version (
+    id SERIAL PRIMARY KEY,
+    version_id BIGINT NOT NULL,
+    is_applied BOOLEAN NOT NULL,
+    tstamp TIMESTAMP DEFAULT now()
+)`,
+}
+
+func (d Dialect) rewrite(sql string) string {
+	if d.Rewrite == nil {
+		return sql
+	}
+	return d.Rewrite(sql)
+}
+
+// Migrator applies migrations to a database.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	dialect    Dialect
+}
+
+// New returns a Migrator applying migrations to db in the given dialect.
+func New(db *sql.DB, migrations []Migration, dialect Dialect) *Migrator {
+	return &Migrator{db: db, migrations: migrations, dialect: dialect}
+}
+
+// Up applies every pending migration in order, each in its own transaction,
+// and returns the ones it applied. It stops at the first failure.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		err := m.inTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, m.dialect.rewrite(migration.Up)); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `INSERT INTO goose_db_version (version_id, is_applied) VALUES ($1, true)`, migration.Version)
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("%s: %w", migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down rolls back the most recently applied migration and returns it.
+func (m *Migrator) Down(ctx context.Context) (Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return Migration{}, err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		err := m.inTx(ctx, func(tx *sql.Tx) error {
+			if migration.Down != "" {
+				if _, err := tx.ExecContext(ctx, m.dialect.rewrite(migration.Down)); err != nil {
+					return err
+				}
+			}
+			_, err := tx.ExecContext(ctx, `DELETE FROM goose_db_version WHERE version_id = $1`, migration.Version)
+			return err
+		})
+		if err != nil {
+			return migration, fmt.Errorf("%s: %w", migration.Name, err)
+		}
+		return migration, nil
+	}
+	return Migration{}, ErrNoApplied
+}
+
+// Status lists every migration and when it was applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		at, ok := applied[migration.Version]
+		statuses = append(statuses, Status{Migration: migration, Applied: ok, AppliedAt: at})
+	}
+	return statuses, nil
+}
+
+// Version returns the highest applied migration version, or 0 for an empty
+// database.
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int64
+	for v := range applied {
+		version = max(version, v)
+	}
+	return version, nil
+}
+
+// applied returns when each applied migration was applied, creating the
+// version table the way goose does if it is missing.
+func (m *Migrator) applied(ctx context.Context) (map[int64]time.Time, error) {
+	_, err := m.db.ExecContext(ctx, m.dialect.VersionTable)
+	if err != nil {
+		return nil, fmt.Errorf("creating version table: %w", err)
+	}
+	// goose records version 0 when it creates the table.
+	_, err = m.db.ExecContext(ctx, `INSERT INTO goose_db_version (version_id, is_applied)
+SELECT 0, true WHERE NOT EXISTS (SELECT 1 FROM goose_db_version)`)
+	if err != nil {
+		return nil, fmt.Errorf("creating version table: %w", err)
+	}
+
+	rows, err := m.db.QueryContext(ctx, `SELECT version_id, is_applied, tstamp FROM goose_db_version ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("reading version table: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var isApplied bool
+		var at sql.NullTime
+		if err := rows.Scan(&version, &isApplied, &at); err != nil {
+			return nil, fmt.Errorf("reading version table: %w", err)
+		}
+		if version == 0 {
+			continue
+		}
+		if isApplied {
+			applied[version] = at.Time
+		} else {
+			delete(applied, version)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading version table: %w", err)
+	}
+	return applied, nil
+}
+
+func (m *Migrator) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
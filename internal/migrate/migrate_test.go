@@ -0,0 +1,79 @@
+package migrate_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/eefret/gator/internal/migrate"
+)
+
+// TestLoad checks that migrations are parsed into their sections and
+// sorted by version rather than by name.
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"10_posts.sql": {Data: []byte("-- +goose Up\nCREATE TABLE posts();\n\n-- +goose Down\nDROP TABLE posts;\n")},
+		"2_users.sql":  {Data: []byte("-- +goose Up\n-- +goose StatementBegin\nCREATE TABLE users();\n-- +goose StatementEnd\n")},
+		"README.md":    {Data: []byte("not a migration")},
+	}
+
+	migrations, err := migrate.Load(fsys)
+	if err != nil {
+		t.Fatalf("Expected Load to succeed, got %v", err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("Expected 2 migrations, got %d", len(migrations))
+	}
+
+	users, posts := migrations[0], migrations[1]
+	if users.Version != 2 || users.Name != "2_users.sql" {
+		t.Errorf("Expected 2_users.sql first, got %d %s", users.Version, users.Name)
+	}
+	if users.Up != "CREATE TABLE users();" || users.Down != "" {
+		t.Errorf("Expected only an up section, got up %q down %q", users.Up, users.Down)
+	}
+	if posts.Up != "CREATE TABLE posts();" || posts.Down != "DROP TABLE posts;" {
+		t.Errorf("Expected both sections, got up %q down %q", posts.Up, posts.Down)
+	}
+}
+
+// TestLoadInvalid checks the malformed files Load rejects.
+func TestLoadInvalid(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"no version":  {"users.sql": {Data: []byte("-- +goose Up\nSELECT 1;")}},
+		"bad version": {"x1_users.sql": {Data: []byte("-- +goose Up\nSELECT 1;")}},
+		"duplicate": {
+			"1_users.sql":  {Data: []byte("-- +goose Up\nSELECT 1;")},
+			"01_feeds.sql": {Data: []byte("-- +goose Up\nSELECT 1;")},
+		},
+		"no up":          {"1_users.sql": {Data: []byte("SELECT 1;")}},
+		"down first":     {"1_users.sql": {Data: []byte("-- +goose Down\nSELECT 1;\n-- +goose Up\nSELECT 1;")}},
+		"unknown option": {"1_users.sql": {Data: []byte("-- +goose Up\n-- +goose ENVSUB ON\nSELECT 1;")}},
+	}
+	for name, fsys := range tests {
+		if _, err := migrate.Load(fsys); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// TestLoadSchema checks the repository's own migrations: numbered without
+// gaps and each one reversible.
+func TestLoadSchema(t *testing.T) {
+	migrations, err := migrate.Load(os.DirFS("../../sql/schema"))
+	if err != nil {
+		t.Fatalf("Expected sql/schema to load, got %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("Expected migrations in sql/schema")
+	}
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Errorf("Expected %s to be version %d", m.Name, i+1)
+		}
+		if strings.TrimSpace(m.Down) == "" {
+			t.Errorf("Expected %s to have a down section", m.Name)
+		}
+	}
+}
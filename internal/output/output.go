@@ -0,0 +1,269 @@
+package output
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Format identifies how listing commands render their results.
+type Format string
+
+const (
+	// FormatText is the default human-readable output of each command.
+	FormatText  Format = ""
+	FormatTable Format = "table"
+	FormatJSON  Format = "json"
+	FormatCSV   Format = "csv"
+	FormatYAML  Format = "yaml"
+)
+
+// ParseFormat validates a user supplied format name.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(name)); f {
+	case FormatTable, FormatJSON, FormatCSV, FormatYAML:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown output format %q (expected table, json, csv or yaml)", name)
+}
+
+// Write renders rows, which must be a slice of structs, to w. Field names are
+// taken from the json struct tags so every format shares the same keys as the
+// database models.
+func Write(w io.Writer, format Format, rows any) error {
+	columns, records, err := flatten(rows)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatTable:
+		return writeTable(w, columns, records)
+	case FormatJSON:
+		return writeJSON(w, columns, records)
+	case FormatCSV:
+		return writeCSV(w, columns, records)
+	case FormatYAML:
+		return writeYAML(w, columns, records)
+	}
+	return fmt.Errorf("unsupported output format %q", format)
+}
+
+// flatten turns a slice of structs into column names and normalized values.
+// Values are nil, string, int64, float64, bool or time.Time.
+func flatten(rows any) ([]string, [][]any, error) {
+	v := reflect.ValueOf(rows)
+	if v.Kind() != reflect.Slice {
+		return nil, nil, fmt.Errorf("output: expected a slice, got %T", rows)
+	}
+
+	elem := v.Type().Elem()
+	if elem.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("output: expected a slice of structs, got %T", rows)
+	}
+
+	var columns []string
+	var indexes []int
+	for i := 0; i < elem.NumField(); i++ {
+		field := elem.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		columns = append(columns, name)
+		indexes = append(indexes, i)
+	}
+
+	records := make([][]any, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		row := v.Index(i)
+		record := make([]any, len(indexes))
+		for j, index := range indexes {
+			value, err := normalize(row.Field(index).Interface())
+			if err != nil {
+				return nil, nil, fmt.Errorf("output: field %s: %v", columns[j], err)
+			}
+			record[j] = value
+		}
+		records = append(records, record)
+	}
+
+	return columns, records, nil
+}
+
+// normalize unwraps driver.Valuer types such as uuid.UUID and sql.NullString
+// so that NULL columns render as empty values instead of {String, Valid} pairs.
+func normalize(value any) (any, error) {
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return nil, err
+		}
+		value = v
+	}
+
+	switch v := value.(type) {
+	case nil, string, int64, float64, bool, time.Time:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case []string:
+		return strings.Join(v, ", "), nil
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case float32:
+		return float64(v), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+// text renders a normalized value for the column based formats.
+func text(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(value)
+}
+
+func writeTable(w io.Writer, columns []string, records [][]any) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = strings.ToUpper(column)
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+
+	for _, record := range records {
+		cells := make([]string, len(record))
+		for i, value := range record {
+			// Tabs and newlines would break the column layout.
+			cells[i] = strings.Join(strings.Fields(text(value)), " ")
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+func writeCSV(w io.Writer, columns []string, records [][]any) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		cells := make([]string, len(record))
+		for i, value := range record {
+			cells[i] = text(value)
+		}
+		if err := cw.Write(cells); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeJSON encodes each record as an object, keeping keys in column order.
+func writeJSON(w io.Writer, columns []string, records [][]any) error {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, record := range records {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('{')
+		for j, value := range record {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(columns[j])
+			data, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			buf.Write(data)
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(']')
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+		return err
+	}
+	out.WriteByte('\n')
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// writeYAML emits a sequence of mappings. Strings are written as double
+// quoted scalars, whose escaping rules are a superset of JSON's.
+func writeYAML(w io.Writer, columns []string, records [][]any) error {
+	var buf bytes.Buffer
+	if len(records) == 0 {
+		buf.WriteString("[]\n")
+	}
+
+	for _, record := range records {
+		for j, value := range record {
+			if j == 0 {
+				buf.WriteString("- ")
+			} else {
+				buf.WriteString("  ")
+			}
+			buf.WriteString(columns[j])
+			buf.WriteString(": ")
+
+			switch v := value.(type) {
+			case nil:
+				buf.WriteString("null")
+			case string:
+				data, _ := json.Marshal(v)
+				buf.Write(data)
+			case time.Time:
+				buf.WriteString(v.Format(time.RFC3339Nano))
+			default:
+				buf.WriteString(text(v))
+			}
+			buf.WriteByte('\n')
+		}
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,137 @@
+package output_test
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eefret/gator/internal/output"
+	"github.com/google/uuid"
+)
+
+type row struct {
+	ID          uuid.UUID      `json:"id"`
+	Name        string         `json:"name"`
+	Description sql.NullString `json:"description"`
+	PublishedAt sql.NullTime   `json:"published_at"`
+}
+
+var (
+	testID   = uuid.MustParse("6f1c1f8e-0b8e-4a4f-9d39-2a8f5f0f6c11")
+	testTime = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	testRows = []row{
+		{
+			ID:          testID,
+			Name:        "Go, \"official\" blog",
+			Description: sql.NullString{String: "news", Valid: true},
+			PublishedAt: sql.NullTime{Time: testTime, Valid: true},
+		},
+		{ID: testID, Name: "empty"},
+	}
+)
+
+func render(t *testing.T, format output.Format, rows any) string {
+	var buf bytes.Buffer
+	if err := output.Write(&buf, format, rows); err != nil {
+		t.Fatalf("Expected Write to succeed, got error: %v", err)
+	}
+	return buf.String()
+}
+
+// TestParseFormat checks that known formats are accepted case-insensitively
+// and unknown ones are rejected.
+func TestParseFormat(t *testing.T) {
+	for _, name := range []string{"table", "JSON", "csv", "yaml"} {
+		if _, err := output.ParseFormat(name); err != nil {
+			t.Errorf("Expected %q to be accepted, got error: %v", name, err)
+		}
+	}
+	if _, err := output.ParseFormat("xml"); err == nil {
+		t.Error("Expected error for unknown format, got nil")
+	}
+}
+
+// TestWriteJSON verifies keys follow the json tags, in field order, and that
+// NULL columns are encoded as null.
+func TestWriteJSON(t *testing.T) {
+	got := render(t, output.FormatJSON, testRows)
+
+	want := `[
+  {
+    "id": "6f1c1f8e-0b8e-4a4f-9d39-2a8f5f0f6c11",
+    "name": "Go, \"official\" blog",
+    "description": "news",
+    "published_at": "2024-03-01T12:00:00Z"
+  },
+  {
+    "id": "6f1c1f8e-0b8e-4a4f-9d39-2a8f5f0f6c11",
+    "name": "empty",
+    "description": null,
+    "published_at": null
+  }
+]
+`
+	if got != want {
+		t.Errorf("Unexpected JSON output:\n%s", got)
+	}
+}
+
+// TestWriteCSV verifies the header row and quoting of embedded commas.
+func TestWriteCSV(t *testing.T) {
+	got := render(t, output.FormatCSV, testRows)
+
+	want := "id,name,description,published_at\n" +
+		"6f1c1f8e-0b8e-4a4f-9d39-2a8f5f0f6c11,\"Go, \"\"official\"\" blog\",news,2024-03-01T12:00:00Z\n" +
+		"6f1c1f8e-0b8e-4a4f-9d39-2a8f5f0f6c11,empty,,\n"
+	if got != want {
+		t.Errorf("Unexpected CSV output:\n%s", got)
+	}
+}
+
+// TestWriteYAML verifies each row becomes a mapping in a sequence.
+func TestWriteYAML(t *testing.T) {
+	got := render(t, output.FormatYAML, testRows[1:])
+
+	want := "- id: \"6f1c1f8e-0b8e-4a4f-9d39-2a8f5f0f6c11\"\n" +
+		"  name: \"empty\"\n" +
+		"  description: null\n" +
+		"  published_at: null\n"
+	if got != want {
+		t.Errorf("Unexpected YAML output:\n%s", got)
+	}
+
+	if got := render(t, output.FormatYAML, []row{}); got != "[]\n" {
+		t.Errorf("Expected empty sequence, got %q", got)
+	}
+}
+
+// TestWriteTable verifies the header is printed even when there are no rows.
+func TestWriteTable(t *testing.T) {
+	got := render(t, output.FormatTable, []row(nil))
+	if strings.TrimSpace(got) != "ID  NAME  DESCRIPTION  PUBLISHED_AT" {
+		t.Errorf("Unexpected table header: %q", got)
+	}
+}
+
+// TestWriteStringSlice checks that list columns such as post categories are
+// joined into a single value.
+func TestWriteStringSlice(t *testing.T) {
+	type tagged struct {
+		Tags []string `json:"tags"`
+	}
+
+	got := render(t, output.FormatCSV, []tagged{{Tags: []string{"go", "security"}}, {}})
+	if want := "tags\n\"go, security\"\n\n"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+// TestWriteRejectsNonSlice checks that passing a single struct is an error.
+func TestWriteRejectsNonSlice(t *testing.T) {
+	var buf bytes.Buffer
+	if err := output.Write(&buf, output.FormatJSON, testRows[0]); err == nil {
+		t.Fatal("Expected error when rows is not a slice, got nil")
+	}
+}
@@ -0,0 +1,242 @@
+// Package webhook delivers new-post notifications to user-configured URLs,
+// signing each request and retrying transient failures.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Headers sent with every delivery. The signature is the hex HMAC-SHA256 of
+// the timestamp, a period and the body, keyed with the webhook's secret.
+const (
+	HeaderEvent     = "X-Gator-Event"
+	HeaderDelivery  = "X-Gator-Delivery"
+	HeaderTimestamp = "X-Gator-Timestamp"
+	HeaderSignature = "X-Gator-Signature"
+)
+
+// EventPostCreated is the event sent for each new post.
+const EventPostCreated = "post.created"
+
+const (
+	// DefaultMaxAttempts is how many times a delivery is tried.
+	DefaultMaxAttempts = 3
+	// DefaultBackoff is the wait before the first retry; it doubles after
+	// each further attempt.
+	DefaultBackoff = time.Second
+	// DefaultTimeout bounds each attempt.
+	DefaultTimeout = 10 * time.Second
+)
+
+// Feed describes the feed a post came from.
+type Feed struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// Post is the new post being announced.
+type Post struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Description string     `json:"description,omitempty"`
+	Author      string     `json:"author,omitempty"`
+	Categories  []string   `json:"categories,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+}
+
+// Payload is the data a delivery is rendered from.
+type Payload struct {
+	Event string `json:"event"`
+	Feed  Feed   `json:"feed"`
+	Post  Post   `json:"post"`
+}
+
+// Template renders payloads into request bodies. An empty template sends the
+// payload as JSON; otherwise it is a text/template over Payload with a json
+// function for escaping values, e.g. {"text": {{json .Post.Title}}}.
+type Template struct {
+	tmpl *template.Template
+}
+
+// ParseTemplate compiles text, which may be empty.
+func ParseTemplate(text string) (*Template, error) {
+	if strings.TrimSpace(text) == "" {
+		return &Template{}, nil
+	}
+
+	tmpl, err := template.New("webhook").Option("missingkey=error").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload template: %v", err)
+	}
+	return &Template{tmpl: tmpl}, nil
+}
+
+// Render produces the request body and its content type.
+func (t *Template) Render(p Payload) ([]byte, string, error) {
+	if t.tmpl == nil {
+		data, err := json.Marshal(p)
+		return data, "application/json", err
+	}
+
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, p); err != nil {
+		return nil, "", fmt.Errorf("rendering payload template: %v", err)
+	}
+
+	contentType := "text/plain; charset=utf-8"
+	if json.Valid(buf.Bytes()) {
+		contentType = "application/json"
+	}
+	return buf.Bytes(), contentType, nil
+}
+
+// NewSecret returns a random signing secret.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Sign returns the signature header value for body sent at timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is valid for body sent at timestamp.
+// Receivers should also reject stale timestamps.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Request is a single delivery.
+type Request struct {
+	URL         string
+	Secret      string
+	DeliveryID  string
+	Event       string
+	Body        []byte
+	ContentType string
+}
+
+// Result reports how a delivery went. Err is nil on success.
+type Result struct {
+	Attempts   int
+	StatusCode int
+	Err        error
+}
+
+// Sender posts deliveries, retrying network errors, 429s and 5xx responses
+// with exponential backoff.
+type Sender struct {
+	Client      *http.Client
+	MaxAttempts int
+	Backoff     time.Duration
+	UserAgent   string
+}
+
+// DefaultSender is used by the aggregator.
+var DefaultSender = &Sender{}
+
+// Send delivers req, trying up to MaxAttempts times.
+func (s *Sender) Send(ctx context.Context, req Request) Result {
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: DefaultTimeout}
+	}
+	attempts := s.MaxAttempts
+	if attempts <= 0 {
+		attempts = DefaultMaxAttempts
+	}
+	backoff := s.Backoff
+	if backoff <= 0 {
+		backoff = DefaultBackoff
+	}
+
+	var result Result
+	for result.Attempts < attempts {
+		if result.Attempts > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				result.Err = ctx.Err()
+				return result
+			}
+			backoff *= 2
+		}
+
+		result.Attempts++
+		status, err := s.attempt(ctx, client, req)
+		result.StatusCode = status
+		result.Err = err
+		if err == nil || !retryable(ctx, status) {
+			return result
+		}
+	}
+	return result
+}
+
+func (s *Sender) attempt(ctx context.Context, client *http.Client, req Request) (int, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return 0, err
+	}
+
+	userAgent := s.UserAgent
+	if userAgent == "" {
+		userAgent = "gator-webhook"
+	}
+	timestamp := time.Now().Unix()
+
+	httpReq.Header.Set("Content-Type", req.ContentType)
+	httpReq.Header.Set("User-Agent", userAgent)
+	httpReq.Header.Set(HeaderEvent, req.Event)
+	httpReq.Header.Set(HeaderDelivery, req.DeliveryID)
+	httpReq.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	httpReq.Header.Set(HeaderSignature, Sign(req.Secret, timestamp, req.Body))
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected HTTP status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// retryable reports whether a failed attempt is worth repeating.
+func retryable(ctx context.Context, status int) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return status == 0 || status == http.StatusTooManyRequests || status >= 500
+}
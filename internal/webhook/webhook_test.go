@@ -0,0 +1,132 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eefret/gator/internal/webhook"
+)
+
+var testPayload = webhook.Payload{
+	Event: webhook.EventPostCreated,
+	Feed:  webhook.Feed{ID: "f1", Name: "Go Blog", URL: "https://go.dev/blog/feed.atom"},
+	Post:  webhook.Post{ID: "p1", Title: `Go 1.24 "released"`, URL: "https://go.dev/blog/go1.24"},
+}
+
+func newSender() *webhook.Sender {
+	return &webhook.Sender{MaxAttempts: 3, Backoff: time.Millisecond}
+}
+
+// TestSendSigned checks the body, headers and signature of a delivery.
+func TestSendSigned(t *testing.T) {
+	var gotBody []byte
+	var gotHeader http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotHeader = r.Header
+	}))
+	defer server.Close()
+
+	tmpl, err := webhook.ParseTemplate("")
+	if err != nil {
+		t.Fatalf("Expected empty template to parse, got %v", err)
+	}
+	body, contentType, err := tmpl.Render(testPayload)
+	if err != nil {
+		t.Fatalf("Expected render to succeed, got %v", err)
+	}
+
+	result := newSender().Send(context.Background(), webhook.Request{
+		URL:         server.URL,
+		Secret:      "s3cret",
+		DeliveryID:  "d1",
+		Event:       webhook.EventPostCreated,
+		Body:        body,
+		ContentType: contentType,
+	})
+	if result.Err != nil || result.Attempts != 1 || result.StatusCode != http.StatusOK {
+		t.Fatalf("Expected one successful attempt, got %+v", result)
+	}
+
+	var decoded webhook.Payload
+	if err := json.Unmarshal(gotBody, &decoded); err != nil || decoded.Post.Title != testPayload.Post.Title {
+		t.Errorf("Unexpected body %s (%v)", gotBody, err)
+	}
+	if gotHeader.Get("Content-Type") != "application/json" || gotHeader.Get(webhook.HeaderEvent) != webhook.EventPostCreated {
+		t.Errorf("Unexpected headers %v", gotHeader)
+	}
+
+	timestamp, _ := strconv.ParseInt(gotHeader.Get(webhook.HeaderTimestamp), 10, 64)
+	if !webhook.Verify("s3cret", timestamp, gotBody, gotHeader.Get(webhook.HeaderSignature)) {
+		t.Errorf("Expected signature to verify")
+	}
+	if webhook.Verify("other", timestamp, gotBody, gotHeader.Get(webhook.HeaderSignature)) {
+		t.Errorf("Expected signature with the wrong secret to fail")
+	}
+}
+
+// TestSendRetries checks that 5xx responses are retried until success.
+func TestSendRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	result := newSender().Send(context.Background(), webhook.Request{URL: server.URL, Body: []byte("{}")})
+	if result.Err != nil || result.Attempts != 3 {
+		t.Errorf("Expected success on the third attempt, got %+v", result)
+	}
+}
+
+// TestSendNoRetryOnClientError checks that 4xx responses fail immediately.
+func TestSendNoRetryOnClientError(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	result := newSender().Send(context.Background(), webhook.Request{URL: server.URL, Body: []byte("{}")})
+	if result.Err == nil || result.Attempts != 1 || result.StatusCode != http.StatusGone || calls.Load() != 1 {
+		t.Errorf("Expected a single failed attempt, got %+v after %d calls", result, calls.Load())
+	}
+}
+
+// TestTemplate checks custom payload templates and JSON escaping.
+func TestTemplate(t *testing.T) {
+	tmpl, err := webhook.ParseTemplate(`{"text": {{json (printf "%s: %s" .Feed.Name .Post.Title)}}}`)
+	if err != nil {
+		t.Fatalf("Expected template to parse, got %v", err)
+	}
+
+	body, contentType, err := tmpl.Render(testPayload)
+	if err != nil {
+		t.Fatalf("Expected render to succeed, got %v", err)
+	}
+	if want := `{"text": "Go Blog: Go 1.24 \"released\""}`; string(body) != want {
+		t.Errorf("Expected %s, got %s", want, body)
+	}
+	if contentType != "application/json" {
+		t.Errorf("Expected JSON content type, got %s", contentType)
+	}
+
+	plain, _ := webhook.ParseTemplate("New: {{.Post.URL}}")
+	if _, contentType, _ := plain.Render(testPayload); contentType != "text/plain; charset=utf-8" {
+		t.Errorf("Expected plain text content type, got %s", contentType)
+	}
+
+	if _, err := webhook.ParseTemplate("{{.Post.Title"); err == nil {
+		t.Errorf("Expected invalid template to fail")
+	}
+}
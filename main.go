@@ -4,14 +4,21 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/eefret/gator/external/rss"
+	"github.com/eefret/gator/internal/article"
 	"github.com/eefret/gator/internal/config"
 	"github.com/eefret/gator/internal/database"
+	"github.com/eefret/gator/internal/htmltext"
+	"github.com/eefret/gator/internal/logging"
+	"github.com/eefret/gator/internal/migrate"
+	"github.com/eefret/gator/internal/output"
 	"github.com/google/uuid"
 
 	_ "github.com/lib/pq"
@@ -19,7 +26,11 @@ import (
 
 type State struct {
 	Config *config.Config
-	db *database.Queries
+	db database.Store
+	// dialect adapts the migrations to db's engine.
+	dialect migrate.Dialect
+	// Output selects how listing commands render their results.
+	Output output.Format
 }
 
 type Command struct {
@@ -48,58 +59,159 @@ func main() {
 	// Read the configuration from ~/.gatorconfig.json
 	cfg, err := config.Read()
 	if err != nil {
-		log.Fatalf("Error reading config: %v", err)
+		fatal("Error reading config", "error", err)
 	}
 
-	db, err := sql.Open("postgres", cfg.DbURL)
+	logger, closer, err := logging.New(logging.Options{
+		Level:  cfg.LogLevel,
+		Format: cfg.LogFormat,
+		File:   cfg.LogFile,
+	})
 	if err != nil {
-		log.Fatalf("Error opening database: %v", err)
+		fatal("Error configuring logging", "error", err)
+	}
+	closeLog = closer
+	defer closeLog()
+	slog.SetDefault(logger)
+	if cfg.LogFile != "" {
+		stderrLog = slog.New(slog.NewTextHandler(os.Stderr, nil))
+	}
+
+	clientOpts := rss.ClientOptions{
+		MaxBodySize:  cfg.MaxFeedBytes,
+		MaxRedirects: cfg.MaxRedirects,
+	}
+	if cfg.FetchTimeout != "" {
+		clientOpts.Timeout, err = time.ParseDuration(cfg.FetchTimeout)
+		if err != nil {
+			fatal("Error parsing fetch_timeout", "error", err)
+		}
 	}
+	rss.DefaultClient = rss.NewClient(clientOpts)
+	article.DefaultFetcher = article.NewFetcher(article.Options{
+		Timeout:     clientOpts.Timeout,
+		MaxBodySize: cfg.MaxArticleBytes,
+	})
 
-	dbQueries := database.New(db)
+	db, dialect, err := openStore(cfg.DbURL)
+	if err != nil {
+		fatal("Error opening database", "error", err)
+	}
 
 	state := &State{
 		Config: cfg,
-		db: dbQueries,
+		db: db,
+		dialect: dialect,
 	}
 
 	// Create a new instance of the commands struct with an initialized map of handler functions.
 	commands := &Commands{
 		commands: make(map[string]func(*State, Command) error),
 	}
+	commands.Register("migrate", handleMigrate)
 	commands.Register("login", handlerLogin)
 	commands.Register("register", handlerRegister)
-	commands.Register("reset", handleReset)
+	commands.Register("reset", middlewareAdmin(handleReset))
+	commands.Register("delete-user", middlewareAdmin(handleDeleteUser))
+	commands.Register("role", middlewareAdmin(handleRole))
+	commands.Register("purge-posts", middlewareAdmin(handlePurgePosts))
+	commands.Register("prune", middlewareAdmin(handlePrune))
 	commands.Register("users", handleUsers)
 	commands.Register("agg", handleAgg)
+	commands.Register("fetch-now", handleFetchNow)
+	commands.Register("fetch", handleFetch)
+	commands.Register("fetch-log", handleFetchLog)
 	commands.Register("addfeed", middlewareLoggedIn(handleAddFeed))
 	commands.Register("feeds", handleFeeds)
+	commands.Register("feed-edit", middlewareFeedManager(handleFeedEdit))
+	commands.Register("delete-feed", middlewareFeedManager(handleDeleteFeed))
 	commands.Register("follow", middlewareLoggedIn(handleFollow))
 	commands.Register("following", middlewareLoggedIn(handleFollowing))
 	commands.Register("unfollow", middlewareLoggedIn(handleUnfollow))
 	commands.Register("browse", middlewareLoggedIn(handleBrowse))
+	commands.Register("tag", middlewareLoggedIn(handleTag))
+	commands.Register("untag", middlewareLoggedIn(handleUntag))
+	commands.Register("rename", middlewareLoggedIn(handleRename))
+	commands.Register("filters", middlewareLoggedIn(handleFilters))
+	commands.Register("webhooks", middlewareLoggedIn(handleWebhooks))
+	commands.Register("digest", handleDigest)
+	commands.Register("full-content", middlewareFeedManager(handleFullContent))
+	commands.Register("retention", middlewareFeedManager(handleRetention))
 
 	// Use os.Args to get the command-line arguments passed in by the user.
 	// The first argument is the name of the program, so we skip it.
-	if len(os.Args) < 2 {
-		log.Fatalf("No command provided")
+	args, format, err := parseGlobalFlags(os.Args[1:])
+	if err != nil {
+		fatal("Error parsing flags", "error", err)
 	}
+	state.Output = format
 
-	commandName := os.Args[1]
-	commandArgs := os.Args[2:]
+	if len(args) == 0 {
+		fatal("No command provided")
+	}
 
 	c := Command{
-		Name: commandName,
-		Arguments: commandArgs,
+		Name: args[0],
+		Arguments: args[1:],
 	}
 
 	if err := commands.Run(state, c); err != nil {
-		log.Fatalf("Error running command: %v", err)
+		fatal("Error running command", "command", c.Name, "error", err)
 	}
 
 
 }
 
+// closeLog closes the log file, if logs go to one. fatal calls it because
+// os.Exit skips deferred calls.
+var closeLog = func() error { return nil }
+
+// stderrLog echoes fatal errors to the terminal when logs go to a file.
+var stderrLog *slog.Logger
+
+// fatal logs msg at error level and exits with a non-zero status.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	if stderrLog != nil {
+		stderrLog.Error(msg, args...)
+	}
+	closeLog()
+	os.Exit(1)
+}
+
+// parseGlobalFlags reads the options shared by every command from the start of
+// args and returns the command and its arguments. Parsing stops at the command
+// name, so a command's own arguments are never taken for global options.
+func parseGlobalFlags(args []string) ([]string, output.Format, error) {
+	format := output.FormatText
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		var value string
+		switch {
+		case arg == "--output" || arg == "-o":
+			if i+1 >= len(args) {
+				return nil, "", fmt.Errorf("%s requires a value", arg)
+			}
+			i++
+			value = args[i]
+		case strings.HasPrefix(arg, "--output="):
+			value = strings.TrimPrefix(arg, "--output=")
+		default:
+			return args[i:], format, nil
+		}
+
+		f, err := output.ParseFormat(value)
+		if err != nil {
+			return nil, "", err
+		}
+		format = f
+	}
+
+	return nil, format, nil
+}
+
 func middlewareLoggedIn(handler func(s *State, cmd Command, user database.User) error) func(*State, Command) error {
 	return func(s *State, cmd Command) error {
 		if s.Config.CurrentUserName == "" {
@@ -118,6 +230,40 @@ func middlewareLoggedIn(handler func(s *State, cmd Command, user database.User)
 	}
 }
 
+// middlewareAdmin is middlewareLoggedIn for commands only admins may run.
+func middlewareAdmin(handler func(s *State, cmd Command, user database.User) error) func(*State, Command) error {
+	return middlewareLoggedIn(func(s *State, cmd Command, user database.User) error {
+		if user.Role != roleAdmin {
+			return fmt.Errorf("Only admins can run %s", cmd.Name)
+		}
+		return handler(s, cmd, user)
+	})
+}
+
+// middlewareFeedManager is middlewareLoggedIn for commands that change the
+// feed their first argument refers to. Only the user who added the feed and
+// admins may run them, and partial names are not accepted.
+func middlewareFeedManager(handler func(s *State, cmd Command, user database.User, feed database.Feed) error) func(*State, Command) error {
+	return middlewareLoggedIn(func(s *State, cmd Command, user database.User) error {
+		if len(cmd.Arguments) == 0 {
+			return fmt.Errorf("usage: %s <feed_url> ...", cmd.Name)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		feed, err := resolveFeedExact(ctx, s, cmd.Arguments[0])
+		if err != nil {
+			return err
+		}
+		if !canManageFeed(user, feed) {
+			return fmt.Errorf("Only the user who added %s or an admin can change it", feed.Url)
+		}
+
+		return handler(s, cmd, user, feed)
+	})
+}
+
 func handlerLogin(s *State, cmd Command) error {
 	if len(cmd.Arguments) == 0 {
 		return fmt.Errorf("No command provided")
@@ -179,30 +325,13 @@ func handlerRegister(s *State, cmd Command) error {
 	// Print a message that the user was created, and log the user’s data to the console for your own debugging.
 	fmt.Printf("User %s has been created successfully!\n", user.Name)
 	fmt.Printf("User ID: %s\n", user.ID)
+	fmt.Printf("Role: %s\n", user.Role)
 	fmt.Printf("Created At: %s\n", user.CreatedAt)
 	fmt.Printf("Updated At: %s\n", user.UpdatedAt)
 
 	return nil
 }
 
-func handleReset(s *State, cmd Command) error {
-	if len(cmd.Arguments) != 0 {
-		return fmt.Errorf("Reset doesnt allow commands")
-	}
-
-	s.Config.CurrentUserName = ""
-	s.Config.SetUser("")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	if err := s.db.ResetUsers(ctx); err != nil {
-		return fmt.Errorf("Error resetting users: %v", err)
-	}
-
-	return nil
-}
-
 func handleUsers(s *State, cmd Command) error {
 	if len(cmd.Arguments) != 0 {
 		return fmt.Errorf("Users doesnt allow commands")
@@ -216,9 +345,20 @@ func handleUsers(s *State, cmd Command) error {
 		return fmt.Errorf("Error listing users: %v", err)
 	}
 
+	if s.Output != output.FormatText {
+		return output.Write(os.Stdout, s.Output, users)
+	}
+
 	for _, user := range users {
+		var notes []string
+		if user.Role == roleAdmin {
+			notes = append(notes, roleAdmin)
+		}
 		if user.Name == s.Config.CurrentUserName {
-			fmt.Printf("* %s (current)\n", user.Name)
+			notes = append(notes, "current")
+		}
+		if len(notes) > 0 {
+			fmt.Printf("* %s (%s)\n", user.Name, strings.Join(notes, ", "))
 		} else {
 			fmt.Printf("* %s\n", user.Name)
 		}
@@ -227,33 +367,6 @@ func handleUsers(s *State, cmd Command) error {
 	return nil
 }
 
-func handleAgg(s *State, cmd Command) error {
-	if len(cmd.Arguments) != 1 {
-		return fmt.Errorf("Agg just requires one argument")
-	}
-
-	timeBetweenRequests, err := time.ParseDuration(cmd.Arguments[0])
-	if err != nil {
-		return fmt.Errorf("Error parsing duration: %v", err)
-	}
-
-	fmt.Println("Collecting feeds every " + timeBetweenRequests.String())
-
-	ticker := time.NewTicker(timeBetweenRequests)
-
-	for ; ; <-ticker.C {
-		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-		err := scrapeFeeds(ctx, s.db)
-		cancel()
-
-		if err != nil {
-			fmt.Printf("Error scraping feeds: %v\n", err)
-		}
-
-	}
-
-}
-
 func handleAddFeed(s *State, cmd Command, user database.User) error {
 	if len(cmd.Arguments) != 2 {
 		return fmt.Errorf(`AddFeed requires name and url arguments. example addfeed "<name>" "<url>"`)
@@ -295,18 +408,24 @@ func handleFeeds(s *State, cmd Command) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	feeds, err := s.db.GetFeeds(ctx)
+	feeds, err := s.db.GetFeedsWithUsers(ctx)
 	if err != nil {
 		return fmt.Errorf("Error getting feeds: %v", err)
 	}
 
+	if s.Output != output.FormatText {
+		return output.Write(os.Stdout, s.Output, feeds)
+	}
+
 	for _, feed := range feeds {
-		user, err := s.db.GetUserById(ctx, feed.UserID)
-		if err != nil {
-			return fmt.Errorf("Error getting user: %v", err)
+		fmt.Printf("* FeedTitle: %s | FeedURL: (%s) | UserName: %s | ID: %s", feed.Name, feed.Url, feed.UserName, shortID(feed.ID))
+		if feed.FetchIntervalSeconds.Valid {
+			fmt.Printf(" | Interval: %s", time.Duration(feed.FetchIntervalSeconds.Int32)*time.Second)
 		}
-
-		fmt.Printf("* FeedTitle: %s | FeedURL: (%s) | UserName: %s\n", feed.Name, feed.Url, user.Name)
+		if !feed.Enabled {
+			fmt.Print(" | Disabled")
+		}
+		fmt.Println()
 	}
 
 	return nil
@@ -314,23 +433,24 @@ func handleFeeds(s *State, cmd Command) error {
 
 func handleFollow(s *State, cmd Command, user database.User) error {
 	if len(cmd.Arguments) != 1 {
-		return fmt.Errorf("Follow requires one argument")
+		return fmt.Errorf("Follow requires one argument: a feed url, id or name")
 	}
 
-	feedURL := cmd.Arguments[0]
-
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	feed, err := s.db.GetFeedByURL(ctx, feedURL)
+	feed, err := resolveFeed(ctx, s, cmd.Arguments[0])
 	if err != nil {
-		return fmt.Errorf("Error getting feed: %v", err)
+		return err
 	}
 
 	row, err := s.db.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
 		UserID: user.ID,
 		FeedID: feed.ID,
 	})
+	if database.IsUniqueViolation(err) {
+		return fmt.Errorf("%s is already following %s", user.Name, feed.Name)
+	}
 	if err != nil {
 		return fmt.Errorf("Error following feed: %v", err)
 	}
@@ -340,9 +460,25 @@ func handleFollow(s *State, cmd Command, user database.User) error {
 	return nil
 }
 
+// followingRow is a follow with its tags, as listed by following.
+type followingRow struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	FeedID    uuid.UUID `json:"feed_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	FeedName  string    `json:"feed_name"`
+	UserName  string    `json:"user_name"`
+	Tags      string    `json:"tags"`
+}
+
 func handleFollowing(s *State, cmd Command, user database.User) error {
-	if len(cmd.Arguments) != 0 {
-		return fmt.Errorf("Following doesnt allow commands")
+	args, tag, err := parseTagFlag(cmd.Arguments)
+	if err != nil {
+		return err
+	}
+	if len(args) != 0 {
+		return fmt.Errorf("usage: %s [--tag <tag>]", cmd.Name)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -353,8 +489,38 @@ func handleFollowing(s *State, cmd Command, user database.User) error {
 		return fmt.Errorf("Error getting follows: %v", err)
 	}
 
+	tags, err := followTags(ctx, s, user)
+	if err != nil {
+		return err
+	}
+
+	rows := []followingRow{}
 	for _, follow := range follows {
-		fmt.Printf("* %s is following %s\n", follow.UserName, follow.FeedName)
+		if tag != "" && !slices.Contains(tags[follow.FeedID], tag) {
+			continue
+		}
+		rows = append(rows, followingRow{
+			ID:        follow.ID,
+			UserID:    follow.UserID,
+			FeedID:    follow.FeedID,
+			CreatedAt: follow.CreatedAt,
+			UpdatedAt: follow.UpdatedAt,
+			FeedName:  follow.FeedName,
+			UserName:  follow.UserName,
+			Tags:      strings.Join(tags[follow.FeedID], ","),
+		})
+	}
+
+	if s.Output != output.FormatText {
+		return output.Write(os.Stdout, s.Output, rows)
+	}
+
+	for _, row := range rows {
+		if row.Tags == "" {
+			fmt.Printf("* %s is following %s\n", row.UserName, row.FeedName)
+		} else {
+			fmt.Printf("* %s is following %s [%s]\n", row.UserName, row.FeedName, strings.ReplaceAll(row.Tags, ",", ", "))
+		}
 	}
 
 	return nil
@@ -362,50 +528,156 @@ func handleFollowing(s *State, cmd Command, user database.User) error {
 
 func handleUnfollow(s *State, cmd Command, user database.User) error {
 	if len(cmd.Arguments) != 1 {
-		return fmt.Errorf("Unfollow requires one argument")
+		return fmt.Errorf("Unfollow requires one argument: a feed url, id or name")
 	}
 
-	feedURL := cmd.Arguments[0]
-
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	err := s.db.DeleteFeedFollow(ctx, database.DeleteFeedFollowParams{
+	follow, err := resolveFollowedFeed(ctx, s, user, cmd.Arguments[0])
+	if err != nil {
+		return err
+	}
+
+	deleted, err := s.db.DeleteFeedFollow(ctx, database.DeleteFeedFollowParams{
 		UserID: user.ID,
-		Url: feedURL,
+		Url:    follow.FeedUrl,
 	})
 	if err != nil {
 		return fmt.Errorf("Error unfollowing feed: %v", err)
 	}
+	if deleted == 0 {
+		return fmt.Errorf("%s is not following %s", user.Name, follow.FeedName)
+	}
 
-	fmt.Printf("%s is no longer following %s\n", user.Name, feedURL)
+	fmt.Printf("%s is no longer following %s\n", user.Name, follow.FeedName)
 
 	return nil
 }
 
+// handleRename sets the user's own name for a feed they follow, shown in
+// following and browse instead of the feed's name. Without a name it
+// restores the feed's own name.
+func handleRename(s *State, cmd Command, user database.User) error {
+	if len(cmd.Arguments) < 1 || len(cmd.Arguments) > 2 {
+		return fmt.Errorf("usage: %s <url> [name]", cmd.Name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	follow, err := getFollow(ctx, s, user, cmd.Arguments[0])
+	if err != nil {
+		return err
+	}
+
+	displayName := sql.NullString{}
+	if len(cmd.Arguments) == 2 {
+		name := strings.TrimSpace(cmd.Arguments[1])
+		displayName = sql.NullString{String: name, Valid: name != ""}
+	}
+
+	err = s.db.SetFeedFollowDisplayName(ctx, database.SetFeedFollowDisplayNameParams{
+		ID:          follow.ID,
+		DisplayName: displayName,
+	})
+	if err != nil {
+		return fmt.Errorf("Error renaming feed: %v", err)
+	}
+
+	if displayName.Valid {
+		fmt.Printf("%s is now shown as %s\n", cmd.Arguments[0], displayName.String)
+	} else {
+		fmt.Printf("%s is shown with its own name again\n", cmd.Arguments[0])
+	}
+	return nil
+}
+
+// handleBrowse shows the newest posts from the feeds the user follows. Posts
+// filters hide are left out, and so are read posts unless --all is given.
 func handleBrowse(s *State, cmd Command, user database.User) error {
+	args, tag, err := parseTagFlag(cmd.Arguments)
+	if err != nil {
+		return err
+	}
+
+	all := false
+	args = slices.DeleteFunc(args, func(arg string) bool {
+		if arg == "--all" {
+			all = true
+			return true
+		}
+		return false
+	})
+
 	limit := 2
-	if len(cmd.Arguments) == 1 {
-		if specifiedLimit, err := strconv.Atoi(cmd.Arguments[0]); err == nil {
+	if len(args) == 1 {
+		if specifiedLimit, err := strconv.Atoi(args[0]); err == nil && specifiedLimit > 0 {
 			limit = specifiedLimit
 		} else {
-			return fmt.Errorf("invalid limit: %w", err)
+			return fmt.Errorf("invalid limit: %s", args[0])
 		}
 	}
 
-	posts, err := s.db.GetPostsForUser(context.Background(), database.GetPostsForUserParams{
-		UserID: user.ID,
-		Limit:  int32(limit),
-	})
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	rules, tags, err := loadFilters(ctx, s, user)
 	if err != nil {
-		return fmt.Errorf("couldn't get posts for user: %w", err)
+		return err
+	}
+
+	// Hidden and read posts don't count towards the limit, so keep paging
+	// until it is filled or the posts run out.
+	var posts []database.GetPostsForUserRow
+	highlighted := make(map[uuid.UUID]bool)
+	for offset := 0; len(posts) < limit; offset += limit {
+		page, err := getPostsPage(ctx, s, user, tag, limit, offset)
+		if err != nil {
+			return fmt.Errorf("couldn't get posts for user: %w", err)
+		}
+
+		for _, post := range page {
+			if len(posts) == limit {
+				break
+			}
+			result := rules.Evaluate(filterPost(post), tags[post.FeedID])
+			if err := storeFilterResult(ctx, s, user, post.ID, result); err != nil {
+				return err
+			}
+			if result.Hide {
+				continue
+			}
+			if !all && (post.ReadAt.Valid || result.MarkRead) {
+				continue
+			}
+			highlighted[post.ID] = result.Highlight
+			posts = append(posts, post)
+		}
+
+		if len(page) < limit {
+			break
+		}
+	}
+
+	if s.Output != output.FormatText {
+		return output.Write(os.Stdout, s.Output, posts)
 	}
 
 	fmt.Printf("Found %d posts for user %s:\n", len(posts), user.Name)
 	for _, post := range posts {
 		fmt.Printf("%s from %s\n", post.PublishedAt.Time.Format("Mon Jan 2"), post.FeedName)
-		fmt.Printf("--- %s ---\n", post.Title)
-		fmt.Printf("    %v\n", post.Description)
+		if highlighted[post.ID] {
+			fmt.Printf("--- ★ %s ---\n", post.Title)
+		} else {
+			fmt.Printf("--- %s ---\n", post.Title)
+		}
+		// Prefer the extracted article over the feed's teaser.
+		if post.Content.Valid {
+			fmt.Println(indent(htmltext.Render(post.Content.String, terminalWidth()-4), "    "))
+		} else if post.Description.Valid {
+			fmt.Println(indent(htmltext.Render(post.Description.String, terminalWidth()-4), "    "))
+		}
 		fmt.Printf("Link: %s\n", post.Url)
 		fmt.Println("=====================================")
 	}
@@ -413,53 +685,72 @@ func handleBrowse(s *State, cmd Command, user database.User) error {
 	return nil
 }
 
-func scrapeFeeds(ctx context.Context, db *database.Queries) error {
-	next, err := db.GetNextFeedToFetch(ctx)
-	if err != nil {
-		return fmt.Errorf("Error getting next feed to fetch: %v", err)
+// getPostsPage returns a page of the user's posts, newest first, limited to
+// feeds carrying tag unless it is empty.
+func getPostsPage(ctx context.Context, s *State, user database.User, tag string, limit, offset int) ([]database.GetPostsForUserRow, error) {
+	if tag == "" {
+		return s.db.GetPostsForUser(ctx, database.GetPostsForUserParams{
+			UserID: user.ID,
+			Limit:  int32(limit),
+			Offset: int32(offset),
+		})
 	}
 
-	fmt.Println("Found a feed to fetch!")
-
-	return scrapeFeed(ctx, db, next)
-}
-
-
-func scrapeFeed(ctx context.Context, db *database.Queries, feed database.Feed) error {
-	err := db.MarkFeedFetched(ctx, feed.ID)
+	tagged, err := s.db.GetPostsForUserByTag(ctx, database.GetPostsForUserByTagParams{
+		UserID: user.ID,
+		Tag:    tag,
+		Limit:  int32(limit),
+		Offset: int32(offset),
+	})
 	if err != nil {
-		return fmt.Errorf("Error marking feed fetched: %v", err)
+		return nil, err
 	}
 
-	feedData, err := rss.FetchFeed(ctx, feed.Url)
-	if err != nil {
-		return fmt.Errorf("Error fetching feed: %v", err)
+	posts := make([]database.GetPostsForUserRow, 0, len(tagged))
+	for _, post := range tagged {
+		posts = append(posts, database.GetPostsForUserRow(post))
 	}
+	return posts, nil
+}
 
-	for _, item := range feedData.Channel.Item {
-		publishedAt := sql.NullTime{}
-		if t, err := time.Parse(time.RFC1123Z, item.PubDate); err == nil {
-			publishedAt = sql.NullTime{
-				Time:  t,
-				Valid: true,
-			}
+// shortID is how IDs are shown in listings.
+func shortID(id uuid.UUID) string {
+	return id.String()[:8]
+}
+
+// resolveIDPrefix finds the one ID in ids starting with prefix, so that the
+// short IDs shown in listings can be used as arguments.
+func resolveIDPrefix(kind, prefix string, ids []uuid.UUID) (uuid.UUID, error) {
+	var matches []uuid.UUID
+	for _, id := range ids {
+		if prefix != "" && strings.HasPrefix(id.String(), strings.ToLower(prefix)) {
+			matches = append(matches, id)
 		}
+	}
+	switch len(matches) {
+	case 0:
+		return uuid.Nil, fmt.Errorf("No %s %s", kind, prefix)
+	case 1:
+		return matches[0], nil
+	}
+	return uuid.Nil, fmt.Errorf("%s id %s is ambiguous", strings.ToUpper(kind[:1])+kind[1:], prefix)
+}
 
-		_, err = db.CreatePost(context.Background(), database.CreatePostParams{
-			FeedID:    feed.ID,
-			Title:     item.Title,
-			Description: sql.NullString{
-				String: item.Description,
-				Valid:  true,
-			},
-			Url:         item.Link,
-			PublishedAt: publishedAt,
-		})
+// terminalWidth returns the width to wrap text at, honoring $COLUMNS.
+func terminalWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 20 {
+		return n
+	}
+	return 80
+}
 
-		if err != nil {
-			return fmt.Errorf("Error creating post: %v", err)
+// indent prefixes every non-empty line of text.
+func indent(text, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
 		}
 	}
-
-	return nil
-}
\ No newline at end of file
+	return strings.Join(lines, "\n")
+}
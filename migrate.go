@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/eefret/gator/internal/migrate"
+	"github.com/eefret/gator/internal/output"
+)
+
+//go:embed sql/schema/*.sql
+var schemaFiles embed.FS
+
+// migrationStatus is a row of migrate status.
+type migrationStatus struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+// newMigrator returns a Migrator for the migrations built into the binary.
+func newMigrator(s *State) (*migrate.Migrator, error) {
+	schema, err := fs.Sub(schemaFiles, "sql/schema")
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := migrate.Load(schema)
+	if err != nil {
+		return nil, fmt.Errorf("Error loading migrations: %v", err)
+	}
+	return migrate.New(s.db.DB(), migrations, s.dialect), nil
+}
+
+// handleMigrate manages the database schema with the migrations built into
+// the binary: migrate up|down|status|version.
+func handleMigrate(s *State, cmd Command) error {
+	if len(cmd.Arguments) != 1 {
+		return fmt.Errorf("usage: %s up|down|status|version", cmd.Name)
+	}
+
+	m, err := newMigrator(s)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	switch cmd.Arguments[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, migration := range applied {
+			fmt.Printf("Applied %s\n", migration.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("Error applying migrations: %v", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("Database is up to date")
+		}
+	case "down":
+		migration, err := m.Down(ctx)
+		if errors.Is(err, migrate.ErrNoApplied) {
+			return fmt.Errorf("No migrations to roll back")
+		}
+		if err != nil {
+			return fmt.Errorf("Error rolling back migration: %v", err)
+		}
+		fmt.Printf("Rolled back %s\n", migration.Name)
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return fmt.Errorf("Error getting migration status: %v", err)
+		}
+
+		rows := []migrationStatus{}
+		for _, status := range statuses {
+			row := migrationStatus{Version: status.Version, Name: status.Name, Applied: status.Applied}
+			if status.Applied && !status.AppliedAt.IsZero() {
+				row.AppliedAt = &status.AppliedAt
+			}
+			rows = append(rows, row)
+		}
+
+		if s.Output != output.FormatText {
+			return output.Write(os.Stdout, s.Output, rows)
+		}
+
+		for _, row := range rows {
+			applied := "Pending"
+			if row.AppliedAt != nil {
+				applied = row.AppliedAt.Format(time.RFC3339)
+			} else if row.Applied {
+				applied = "Applied"
+			}
+			fmt.Printf("* %-25s %s\n", row.Name, applied)
+		}
+	case "version":
+		version, err := m.Version(ctx)
+		if err != nil {
+			return fmt.Errorf("Error getting schema version: %v", err)
+		}
+		fmt.Println(version)
+	default:
+		return fmt.Errorf("usage: %s up|down|status|version", cmd.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eefret/gator/internal/archive"
+	"github.com/eefret/gator/internal/config"
+	"github.com/eefret/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+// prunedPostsMargin is how long past a feed's retention window the URLs of
+// pruned posts are remembered. Feeds list recent items, so by then they have
+// long dropped them; a URL a feed still lists is stored and pruned again.
+const prunedPostsMargin = 180 * 24 * time.Hour
+
+// retentionPolicy bounds the posts a feed keeps. Zero disables a limit.
+type retentionPolicy struct {
+	Days  int
+	Posts int
+}
+
+// defaultRetention is the global policy from the config.
+func defaultRetention(cfg *config.Config) retentionPolicy {
+	return retentionPolicy{Days: cfg.RetentionDays, Posts: cfg.RetentionPosts}
+}
+
+// forFeed applies the feed's overrides to p.
+func (p retentionPolicy) forFeed(feed database.Feed) retentionPolicy {
+	if feed.RetentionDays.Valid {
+		p.Days = int(feed.RetentionDays.Int32)
+	}
+	if feed.RetentionPosts.Valid {
+		p.Posts = int(feed.RetentionPosts.Int32)
+	}
+	return p
+}
+
+func (p retentionPolicy) String() string {
+	var limits []string
+	if p.Days > 0 {
+		limits = append(limits, plural(int64(p.Days), "day"))
+	}
+	if p.Posts > 0 {
+		limits = append(limits, plural(int64(p.Posts), "post"))
+	}
+	if len(limits) == 0 {
+		return "keep forever"
+	}
+	return "keep " + strings.Join(limits, ", ")
+}
+
+// archivedPost is the JSON line written for each pruned post.
+type archivedPost struct {
+	ID          uuid.UUID  `json:"id"`
+	FeedID      uuid.UUID  `json:"feed_id"`
+	FeedName    string     `json:"feed_name"`
+	FeedUrl     string     `json:"feed_url"`
+	Title       string     `json:"title"`
+	Url         string     `json:"url"`
+	Author      string     `json:"author,omitempty"`
+	Categories  []string   `json:"categories,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Content     string     `json:"content,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	PrunedAt    time.Time  `json:"pruned_at"`
+}
+
+func newArchivedPost(feed database.Feed, post database.Post, now time.Time) archivedPost {
+	a := archivedPost{
+		ID:          post.ID,
+		FeedID:      feed.ID,
+		FeedName:    feed.Name,
+		FeedUrl:     feed.Url,
+		Title:       post.Title,
+		Url:         post.Url,
+		Author:      post.Author.String,
+		Categories:  post.Categories,
+		Description: post.Description.String,
+		Content:     post.Content.String,
+		CreatedAt:   post.CreatedAt,
+		PrunedAt:    now,
+	}
+	if post.PublishedAt.Valid {
+		a.PublishedAt = &post.PublishedAt.Time
+	}
+	return a
+}
+
+// pruneResult is how many posts retention removed from one feed.
+type pruneResult struct {
+	Feed   database.Feed
+	Policy retentionPolicy
+	Pruned int64
+}
+
+// prunePosts enforces retention on feeds, each in its own transaction. Saved
+// posts are always kept. When archivePath is set, posts are appended to it
+// before they are deleted. Remembered URLs older than the retention window
+// plus prunedPostsMargin are forgotten. With dryRun nothing is archived or
+// deleted and the results count what would be.
+func prunePosts(ctx context.Context, db database.Store, defaults retentionPolicy, feeds []database.Feed, archivePath string, dryRun bool) (results []pruneResult, err error) {
+	var w *archive.Writer
+	if archivePath != "" && !dryRun {
+		w, err = archive.Open(archivePath)
+		if err != nil {
+			return nil, fmt.Errorf("Error opening archive: %v", err)
+		}
+		defer func() {
+			if closeErr := w.Close(); closeErr != nil && err == nil {
+				err = fmt.Errorf("Error closing archive: %v", closeErr)
+			}
+		}()
+	}
+
+	for _, feed := range feeds {
+		policy := defaults.forFeed(feed)
+		now := time.Now()
+
+		if !dryRun {
+			cutoff := now.AddDate(0, 0, -max(policy.Days, 0)).Add(-prunedPostsMargin)
+			_, err := db.DeletePrunedPostsBefore(ctx, database.DeletePrunedPostsBeforeParams{FeedID: feed.ID, Cutoff: cutoff})
+			if err != nil {
+				return results, fmt.Errorf("%s: Error forgetting pruned posts: %v", feed.Url, err)
+			}
+		}
+
+		if policy.Days <= 0 && policy.Posts <= 0 {
+			continue
+		}
+
+		params := database.GetPrunablePostsParams{FeedID: feed.ID}
+		if policy.Days > 0 {
+			params.Cutoff = sql.NullTime{Time: now.AddDate(0, 0, -policy.Days), Valid: true}
+		}
+		if policy.Posts > 0 {
+			params.Keep = sql.NullInt32{Int32: int32(policy.Posts), Valid: true}
+		}
+
+		var pruned int64
+		err := db.InTx(ctx, func(q database.Querier) error {
+			posts, err := q.GetPrunablePosts(ctx, params)
+			if err != nil {
+				return fmt.Errorf("Error getting posts to prune: %v", err)
+			}
+			if dryRun || len(posts) == 0 {
+				pruned = int64(len(posts))
+				return nil
+			}
+
+			ids := make([]uuid.UUID, 0, len(posts))
+			for _, post := range posts {
+				if w != nil {
+					if err := w.Write(newArchivedPost(feed, post, now)); err != nil {
+						return fmt.Errorf("Error archiving post: %v", err)
+					}
+				}
+				ids = append(ids, post.ID)
+			}
+			if w != nil {
+				if err := w.Flush(); err != nil {
+					return fmt.Errorf("Error archiving posts: %v", err)
+				}
+			}
+
+			// Remember the URLs so the next fetch doesn't store them again.
+			if err := q.CreatePrunedPosts(ctx, ids); err != nil {
+				return fmt.Errorf("Error recording pruned posts: %v", err)
+			}
+			pruned, err = q.DeletePostsByID(ctx, ids)
+			if err != nil {
+				return fmt.Errorf("Error deleting posts: %v", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return results, fmt.Errorf("%s: %w", feed.Url, err)
+		}
+
+		results = append(results, pruneResult{Feed: feed, Policy: policy, Pruned: pruned})
+	}
+
+	return results, nil
+}
+
+// handlePrune enforces post retention: prune [--feed <url>] [--archive <file>]
+// [--dry-run].
+func handlePrune(s *State, cmd Command, user database.User) error {
+	usage := fmt.Errorf("usage: %s [--feed <feed_url>] [--archive <file>] [--dry-run]", cmd.Name)
+
+	var feedRef string
+	archivePath := s.Config.RetentionArchive
+	dryRun := false
+	for i := 0; i < len(cmd.Arguments); i++ {
+		switch arg := cmd.Arguments[i]; arg {
+		case "--feed", "--archive":
+			if i+1 >= len(cmd.Arguments) {
+				return fmt.Errorf("%s requires a value", arg)
+			}
+			i++
+			if arg == "--feed" {
+				feedRef = cmd.Arguments[i]
+			} else {
+				archivePath = cmd.Arguments[i]
+			}
+		case "--dry-run":
+			dryRun = true
+		default:
+			return usage
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	var feeds []database.Feed
+	if feedRef != "" {
+		feed, err := resolveFeedExact(ctx, s, feedRef)
+		if err != nil {
+			return err
+		}
+		feeds = []database.Feed{feed}
+	} else {
+		var err error
+		feeds, err = s.db.GetFeeds(ctx)
+		if err != nil {
+			return fmt.Errorf("Error getting feeds: %v", err)
+		}
+	}
+
+	results, err := prunePosts(ctx, s.db, defaultRetention(s.Config), feeds, archivePath, dryRun)
+
+	verb := "Pruned"
+	if dryRun {
+		verb = "Would prune"
+	}
+	var total int64
+	for _, result := range results {
+		total += result.Pruned
+		if result.Pruned > 0 {
+			fmt.Printf("* %s (%s): %s\n", result.Feed.Name, result.Policy, plural(result.Pruned, "post"))
+		}
+	}
+	fmt.Printf("%s %s from %s\n", verb, plural(total, "post"), plural(int64(len(results)), "feed"))
+	if total > 0 && archivePath != "" && !dryRun {
+		fmt.Printf("Archived to %s\n", archivePath)
+	}
+
+	if err != nil {
+		return fmt.Errorf("Error pruning posts: %v", err)
+	}
+	return nil
+}
+
+// handleRetention shows or overrides a feed's post retention:
+// retention <url> [--days N] [--posts N], or retention <url> default to
+// go back to the global policy. A limit of 0 keeps posts forever.
+func handleRetention(s *State, cmd Command, user database.User, feed database.Feed) error {
+	usage := fmt.Errorf("usage: %s <url> [--days N] [--posts N] | %s <url> default", cmd.Name, cmd.Name)
+	if len(cmd.Arguments) < 1 {
+		return usage
+	}
+
+	params := database.SetFeedRetentionParams{}
+	reset := false
+	args := cmd.Arguments[1:]
+	switch {
+	case len(args) == 1 && args[0] == "default":
+		reset = true
+	default:
+		for i := 0; i < len(args); i++ {
+			arg := args[i]
+			if (arg != "--days" && arg != "--posts") || i+1 >= len(args) {
+				return usage
+			}
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid %s value: %s", arg, args[i])
+			}
+			if arg == "--days" {
+				params.RetentionDays = sql.NullInt32{Int32: int32(n), Valid: true}
+			} else {
+				params.RetentionPosts = sql.NullInt32{Int32: int32(n), Valid: true}
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if len(args) > 0 {
+		// Limits that are not given keep their current override.
+		if !reset {
+			if !params.RetentionDays.Valid {
+				params.RetentionDays = feed.RetentionDays
+			}
+			if !params.RetentionPosts.Valid {
+				params.RetentionPosts = feed.RetentionPosts
+			}
+		}
+		params.ID = feed.ID
+		if err := s.db.SetFeedRetention(ctx, params); err != nil {
+			return fmt.Errorf("Error updating feed: %v", err)
+		}
+		feed.RetentionDays = params.RetentionDays
+		feed.RetentionPosts = params.RetentionPosts
+	}
+
+	source := "default"
+	if feed.RetentionDays.Valid || feed.RetentionPosts.Valid {
+		source = "feed override"
+	}
+	fmt.Printf("Retention for %s: %s (%s)\n", feed.Name, defaultRetention(s.Config).forFeed(feed), source)
+	return nil
+}
+
+// maybePrunePosts enforces post retention when prune_interval has passed
+// since the last run.
+func (a *aggregator) maybePrunePosts(ctx context.Context) {
+	if a.pruneInterval <= 0 || ctx.Err() != nil || time.Since(a.lastPostPrune) < a.pruneInterval {
+		return
+	}
+	a.lastPostPrune = time.Now()
+
+	pruneCtx, cancel := context.WithTimeout(ctx, postPruneTimeout)
+	defer cancel()
+
+	feeds, err := a.db.GetFeeds(pruneCtx)
+	if err != nil {
+		slog.Warn("Error getting feeds to prune", "error", err)
+		return
+	}
+
+	results, err := prunePosts(pruneCtx, a.db, a.postRetention, feeds, a.postArchive, false)
+	var total int64
+	for _, result := range results {
+		total += result.Pruned
+	}
+	if err != nil {
+		slog.Warn("Error pruning posts", "pruned", total, "error", err)
+		return
+	}
+	slog.Info("Pruned posts", "pruned", total, "feeds", len(results))
+}
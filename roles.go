@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/eefret/gator/internal/database"
+)
+
+// User roles. Admins manage users and every feed; members manage the feeds
+// they added.
+const (
+	roleAdmin  = "admin"
+	roleMember = "member"
+)
+
+// canManageFeed reports whether user may rename, move or delete feed.
+func canManageFeed(user database.User, feed database.Feed) bool {
+	return user.Role == roleAdmin || feed.UserID == user.ID
+}
+
+// checkNotLastAdmin refuses to remove the only remaining admin, which would
+// leave nobody able to manage users.
+func checkNotLastAdmin(ctx context.Context, s *State) error {
+	admins, err := s.db.CountAdmins(ctx)
+	if err != nil {
+		return fmt.Errorf("Error counting admins: %v", err)
+	}
+	if admins <= 1 {
+		return fmt.Errorf("Cannot remove the last admin")
+	}
+	return nil
+}
+
+// handleRole shows or changes a user's role: role <name> [admin|member].
+func handleRole(s *State, cmd Command, admin database.User) error {
+	if len(cmd.Arguments) < 1 || len(cmd.Arguments) > 2 {
+		return fmt.Errorf("usage: %s <name> [%s|%s]", cmd.Name, roleAdmin, roleMember)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := s.db.GetUser(ctx, cmd.Arguments[0])
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("User %s does not exist", cmd.Arguments[0])
+	}
+	if err != nil {
+		return fmt.Errorf("Error getting user: %v", err)
+	}
+
+	if len(cmd.Arguments) == 1 {
+		fmt.Printf("%s is %s\n", user.Name, user.Role)
+		return nil
+	}
+
+	role := cmd.Arguments[1]
+	if role != roleAdmin && role != roleMember {
+		return fmt.Errorf("usage: %s <name> [%s|%s]", cmd.Name, roleAdmin, roleMember)
+	}
+	if role == user.Role {
+		fmt.Printf("%s is already %s\n", user.Name, role)
+		return nil
+	}
+	if user.Role == roleAdmin {
+		if err := checkNotLastAdmin(ctx, s); err != nil {
+			return err
+		}
+	}
+
+	err = s.db.SetUserRole(ctx, database.SetUserRoleParams{ID: user.ID, Role: role})
+	if err != nil {
+		return fmt.Errorf("Error updating user: %v", err)
+	}
+
+	fmt.Printf("%s is now %s\n", user.Name, role)
+	return nil
+}
@@ -0,0 +1,33 @@
+-- name: CountAllData :one
+SELECT
+    (SELECT count(*) FROM users) AS users,
+    (SELECT count(*) FROM feeds) AS feeds,
+    (SELECT count(*) FROM feed_follows) AS follows,
+    (SELECT count(*) FROM posts) AS posts;
+
+-- name: CountUserData :one
+SELECT
+    (SELECT count(*) FROM feeds WHERE feeds.user_id = $1) AS feeds,
+    (SELECT count(*) FROM feed_follows WHERE feed_follows.user_id = $1) AS follows,
+    (SELECT count(*) FROM posts
+        INNER JOIN feeds ON feeds.id = posts.feed_id
+        WHERE feeds.user_id = $1) AS posts;
+
+-- name: CountFeedData :one
+SELECT
+    (SELECT count(*) FROM feed_follows WHERE feed_follows.feed_id = $1) AS follows,
+    (SELECT count(*) FROM posts WHERE posts.feed_id = $1) AS posts;
+
+-- name: DeleteUser :execrows
+DELETE FROM users WHERE id = $1;
+
+-- name: GetPostIDsOlderThan :many
+SELECT posts.id FROM posts
+WHERE COALESCE(posts.published_at, posts.created_at) < sqlc.arg(cutoff)
+  AND (sqlc.narg(feed_id)::uuid IS NULL OR posts.feed_id = sqlc.narg(feed_id))
+  AND NOT EXISTS (
+    SELECT 1 FROM post_states
+    WHERE post_states.post_id = posts.id
+      AND post_states.saved_at IS NOT NULL
+  )
+ORDER BY posts.id;
@@ -0,0 +1,17 @@
+-- name: GetDigestPostsForUser :many
+-- Posts come oldest first. A page continues after the last post of the
+-- previous one: created at since with an id above after_id.
+SELECT posts.*, COALESCE(feed_follows.display_name, feeds.name) AS feed_name, post_states.read_at FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_states ON post_states.post_id = posts.id
+  AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+  AND (
+    posts.created_at > sqlc.arg(since)
+    OR (posts.created_at = sqlc.arg(since) AND posts.id > sqlc.narg(after_id))
+  )
+  AND posts.created_at <= sqlc.arg(until)
+  AND post_states.read_at IS NULL
+ORDER BY posts.created_at ASC, posts.id ASC
+LIMIT sqlc.arg(page_size);
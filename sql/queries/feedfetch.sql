@@ -0,0 +1,32 @@
+-- name: CreateFeedFetch :one
+INSERT INTO feed_fetches (feed_id, started_at, finished_at, http_status, bytes, items, new_posts, error)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+)
+RETURNING *;
+
+-- name: GetFeedFetches :many
+SELECT feed_fetches.*, feeds.name AS feed_name, feeds.url AS feed_url
+FROM feed_fetches
+INNER JOIN feeds ON feeds.id = feed_fetches.feed_id
+ORDER BY feed_fetches.started_at DESC
+LIMIT $1;
+
+-- name: GetFeedFetchesForFeed :many
+SELECT feed_fetches.*, feeds.name AS feed_name, feeds.url AS feed_url
+FROM feed_fetches
+INNER JOIN feeds ON feeds.id = feed_fetches.feed_id
+WHERE feeds.url = $1
+ORDER BY feed_fetches.started_at DESC
+LIMIT $2;
+
+-- name: DeleteFeedFetchesBefore :execrows
+DELETE FROM feed_fetches
+WHERE started_at < $1;
@@ -14,16 +14,22 @@ INNER JOIN users ON users.id = inserted_feed_follow.user_id;
 
 -- name: GetFeedFollowsForUser :many
 SELECT ff.id, ff.user_id, ff.feed_id, ff.created_at, ff.updated_at,
-	       f.name AS feed_name,
-	       u.name AS user_name
+	       COALESCE(ff.display_name, f.name) AS feed_name,
+	       u.name AS user_name,
+	       f.url AS feed_url
 	FROM feed_follows ff
 	INNER JOIN feeds f ON f.id = ff.feed_id
 	INNER JOIN users u ON u.id = ff.user_id
 	WHERE ff.user_id = $1;
 
--- name: DeleteFeedFollow :exec
+-- name: DeleteFeedFollow :execrows
 DELETE FROM feed_follows ff
 USING feeds f
 WHERE ff.user_id = $1
   AND f.url = $2
-  AND ff.feed_id = f.id;
\ No newline at end of file
+  AND ff.feed_id = f.id;
+
+-- name: SetFeedFollowDisplayName :exec
+UPDATE feed_follows
+SET display_name = $2, updated_at = now()
+WHERE id = $1;
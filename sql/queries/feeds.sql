@@ -22,6 +22,41 @@ SET last_fetched_at = now(), updated_at = now()
 WHERE id = $1;
 
 -- name: GetNextFeedToFetch :one
+-- Feeds that are disabled or fetched more recently than their own interval
+-- are skipped.
 SELECT * FROM feeds
+WHERE enabled
+  AND (
+    fetch_interval_seconds IS NULL
+    OR last_fetched_at IS NULL
+    OR last_fetched_at + make_interval(secs => fetch_interval_seconds) <= now()
+  )
 ORDER BY last_fetched_at ASC NULLS FIRST
 LIMIT 1;
+
+-- name: GetFeedsWithUsers :many
+SELECT feeds.*, users.name AS user_name FROM feeds
+INNER JOIN users ON users.id = feeds.user_id
+ORDER BY feeds.created_at ASC;
+
+-- name: SetFeedFetchFullContent :exec
+UPDATE feeds
+SET fetch_full_content = $2, updated_at = now()
+WHERE id = $1;
+
+-- name: SetFeedRetention :exec
+UPDATE feeds
+SET retention_days = $2, retention_posts = $3, updated_at = now()
+WHERE id = $1;
+
+-- name: UpdateFeed :one
+-- Fields left NULL keep their value. The interval is only changed when
+-- set_interval is true, so it can also be cleared.
+UPDATE feeds
+SET name = COALESCE(sqlc.narg(name), name),
+    url = COALESCE(sqlc.narg(url), url),
+    fetch_interval_seconds = CASE WHEN sqlc.arg(set_interval)::bool THEN sqlc.narg(fetch_interval_seconds) ELSE fetch_interval_seconds END,
+    enabled = COALESCE(sqlc.narg(enabled), enabled),
+    updated_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
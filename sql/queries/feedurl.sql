@@ -0,0 +1,55 @@
+-- name: UpdateFeedURL :exec
+UPDATE feeds
+SET url = $2, updated_at = now()
+WHERE id = $1;
+
+-- name: MoveFeedFollows :exec
+INSERT INTO feed_follows (feed_id, user_id, created_at, updated_at, display_name)
+SELECT sqlc.arg(to_feed_id), user_id, created_at, now(), display_name
+FROM feed_follows
+WHERE feed_follows.feed_id = sqlc.arg(from_feed_id)
+ON CONFLICT (feed_id, user_id) DO NOTHING;
+
+-- name: MoveFollowTags :exec
+INSERT INTO follow_tags (feed_follow_id, tag, created_at)
+SELECT target.id, ft.tag, ft.created_at
+FROM follow_tags ft
+INNER JOIN feed_follows source ON source.id = ft.feed_follow_id
+INNER JOIN feed_follows target ON target.user_id = source.user_id
+  AND target.feed_id = sqlc.arg(to_feed_id)
+WHERE source.feed_id = sqlc.arg(from_feed_id)
+ON CONFLICT DO NOTHING;
+
+-- name: MovePosts :exec
+UPDATE posts
+SET feed_id = sqlc.arg(to_feed_id), updated_at = now()
+WHERE feed_id = sqlc.arg(from_feed_id);
+
+-- name: MoveFeedFetches :exec
+UPDATE feed_fetches
+SET feed_id = sqlc.arg(to_feed_id)
+WHERE feed_id = sqlc.arg(from_feed_id);
+
+-- name: MoveFeedURLHistory :exec
+UPDATE feed_url_history
+SET feed_id = sqlc.arg(to_feed_id)
+WHERE feed_id = sqlc.arg(from_feed_id);
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1;
+
+-- name: CreateFeedURLChange :one
+INSERT INTO feed_url_history (feed_id, old_url, new_url, reason)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING *;
+
+-- name: GetFeedURLHistory :many
+SELECT * FROM feed_url_history
+WHERE feed_id = $1
+ORDER BY created_at DESC;
@@ -0,0 +1,19 @@
+-- name: CreateFilterRule :one
+INSERT INTO filter_rules (user_id, field, pattern, is_regex, action, feed_id, tag)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetFilterRulesForUser :many
+SELECT filter_rules.*, feeds.url AS feed_url FROM filter_rules
+LEFT JOIN feeds ON feeds.id = filter_rules.feed_id
+WHERE filter_rules.user_id = $1
+ORDER BY filter_rules.created_at ASC;
+
+-- name: DeleteFilterRule :execrows
+DELETE FROM filter_rules
+WHERE user_id = $1 AND id = $2;
+
+-- name: MoveFilterRules :exec
+UPDATE filter_rules
+SET feed_id = sqlc.arg(to_feed_id)::uuid
+WHERE feed_id = sqlc.arg(from_feed_id)::uuid;
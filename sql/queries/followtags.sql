@@ -0,0 +1,19 @@
+-- name: GetFeedFollowForUser :one
+SELECT ff.* FROM feed_follows ff
+INNER JOIN feeds f ON f.id = ff.feed_id
+WHERE ff.user_id = $1 AND f.url = $2;
+
+-- name: CreateFollowTag :exec
+INSERT INTO follow_tags (feed_follow_id, tag)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteFollowTag :execrows
+DELETE FROM follow_tags
+WHERE feed_follow_id = $1 AND tag = $2;
+
+-- name: GetFollowTagsForUser :many
+SELECT ff.feed_id, ft.tag FROM follow_tags ft
+INNER JOIN feed_follows ff ON ff.id = ft.feed_follow_id
+WHERE ff.user_id = $1
+ORDER BY ft.tag;
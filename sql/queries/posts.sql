@@ -1,18 +1,42 @@
 -- name: CreatePost :one
-INSERT INTO posts (title, url, description, published_at, feed_id)
-VALUES (
-    $1,
-    $2,
-    $3,
-    $4,
-    $5
+-- Posts retention pruned are not stored again.
+INSERT INTO posts (title, url, description, published_at, feed_id, author, categories)
+SELECT
+    sqlc.arg(title)::text,
+    sqlc.arg(url)::text,
+    sqlc.narg(description)::text,
+    sqlc.narg(published_at)::timestamptz,
+    sqlc.arg(feed_id)::uuid,
+    sqlc.narg(author)::text,
+    sqlc.arg(categories)::text[]
+WHERE NOT EXISTS (
+    SELECT 1 FROM pruned_posts WHERE pruned_posts.url = sqlc.arg(url)
 )
+ON CONFLICT (url) DO NOTHING
 RETURNING *;
 
 -- name: GetPostsForUser :many
-SELECT posts.*, feeds.name AS feed_name FROM posts
+SELECT posts.*, COALESCE(feed_follows.display_name, feeds.name) AS feed_name, post_states.read_at FROM posts
 JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
 JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_states ON post_states.post_id = posts.id
+  AND post_states.user_id = feed_follows.user_id
 WHERE feed_follows.user_id = $1
-ORDER BY posts.published_at DESC
-LIMIT $2;
\ No newline at end of file
+ORDER BY posts.published_at DESC, posts.id ASC
+LIMIT $2 OFFSET $3;
+
+-- name: SetPostContent :exec
+UPDATE posts
+SET content = $2, updated_at = now()
+WHERE id = $1;
+
+-- name: GetPostsForUserByTag :many
+SELECT posts.*, COALESCE(feed_follows.display_name, feeds.name) AS feed_name, post_states.read_at FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN follow_tags ON follow_tags.feed_follow_id = feed_follows.id
+JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_states ON post_states.post_id = posts.id
+  AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND follow_tags.tag = $2
+ORDER BY posts.published_at DESC, posts.id ASC
+LIMIT $3 OFFSET $4;
@@ -0,0 +1,11 @@
+-- name: MarkPostRead :exec
+INSERT INTO post_states (user_id, post_id, read_at)
+VALUES ($1, $2, now())
+ON CONFLICT (user_id, post_id)
+DO UPDATE SET read_at = COALESCE(post_states.read_at, excluded.read_at);
+
+-- name: SavePost :exec
+INSERT INTO post_states (user_id, post_id, saved_at)
+VALUES ($1, $2, now())
+ON CONFLICT (user_id, post_id)
+DO UPDATE SET saved_at = COALESCE(post_states.saved_at, excluded.saved_at);
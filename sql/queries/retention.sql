@@ -0,0 +1,37 @@
+-- name: GetPrunablePosts :many
+SELECT posts.* FROM posts
+WHERE posts.feed_id = sqlc.arg(feed_id)
+  AND (
+    COALESCE(posts.published_at, posts.created_at) < sqlc.narg(cutoff)
+    OR posts.id NOT IN (
+      SELECT newest.id FROM posts AS newest
+      WHERE newest.feed_id = sqlc.arg(feed_id)
+      ORDER BY COALESCE(newest.published_at, newest.created_at) DESC
+      LIMIT sqlc.narg(keep)
+    )
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM post_states
+    WHERE post_states.post_id = posts.id
+      AND post_states.saved_at IS NOT NULL
+  )
+ORDER BY COALESCE(posts.published_at, posts.created_at) ASC;
+
+-- name: DeletePostsByID :execrows
+DELETE FROM posts WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: DeletePrunedPostsBefore :execrows
+-- Pruned URLs are forgotten once the feed is unlikely to list them again.
+DELETE FROM pruned_posts
+WHERE feed_id = sqlc.arg(feed_id) AND pruned_at < sqlc.arg(cutoff);
+
+-- name: CreatePrunedPosts :exec
+INSERT INTO pruned_posts (url, feed_id)
+SELECT url, feed_id FROM posts
+WHERE id = ANY(sqlc.arg(ids)::uuid[])
+ON CONFLICT (url) DO NOTHING;
+
+-- name: MovePrunedPosts :exec
+UPDATE pruned_posts
+SET feed_id = sqlc.arg(to_feed_id)
+WHERE feed_id = sqlc.arg(from_feed_id);
@@ -1,10 +1,12 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name)
+-- The first user becomes an admin so a fresh install can be managed.
+INSERT INTO users (id, created_at, updated_at, name, role)
 VALUES (
     $1,
     $2,
     $3,
-    $4
+    $4,
+    CASE WHEN EXISTS (SELECT 1 FROM users WHERE role = 'admin') THEN 'member' ELSE 'admin' END
 )
 RETURNING *;
 
@@ -20,4 +22,22 @@ WHERE id = $1;
 DELETE FROM users;
 
 -- name: GetUsers :many
-SELECT * FROM users;
\ No newline at end of file
+SELECT * FROM users;
+
+-- name: SetUserEmail :exec
+UPDATE users
+SET email = $2, updated_at = now()
+WHERE id = $1;
+
+-- name: SetUserLastDigestAt :exec
+UPDATE users
+SET last_digest_at = $2
+WHERE id = $1;
+
+-- name: SetUserRole :exec
+UPDATE users
+SET role = $2, updated_at = now()
+WHERE id = $1;
+
+-- name: CountAdmins :one
+SELECT count(*) FROM users WHERE role = 'admin';
@@ -0,0 +1,43 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (user_id, url, secret, feed_id, tag, keywords, payload_template)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetWebhooksForUser :many
+SELECT webhooks.*, feeds.url AS feed_url FROM webhooks
+LEFT JOIN feeds ON feeds.id = webhooks.feed_id
+WHERE webhooks.user_id = $1
+ORDER BY webhooks.created_at ASC;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks
+WHERE user_id = $1 AND id = $2;
+
+-- name: MoveWebhooks :exec
+UPDATE webhooks
+SET feed_id = sqlc.arg(to_feed_id)::uuid
+WHERE feed_id = sqlc.arg(from_feed_id)::uuid;
+
+-- name: GetWebhooksForFeed :many
+SELECT webhooks.*, feed_follows.display_name AS follow_display_name FROM webhooks
+INNER JOIN feed_follows ON feed_follows.user_id = webhooks.user_id
+  AND feed_follows.feed_id = sqlc.arg(feed_id)
+WHERE (webhooks.feed_id IS NULL OR webhooks.feed_id = sqlc.arg(feed_id))
+  AND (webhooks.tag IS NULL OR EXISTS (
+    SELECT 1 FROM follow_tags
+    WHERE follow_tags.feed_follow_id = feed_follows.id
+      AND follow_tags.tag = webhooks.tag
+  ));
+
+-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (webhook_id, post_id, attempts, status_code, error)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetWebhookDeliveriesForUser :many
+SELECT webhook_deliveries.*, webhooks.url AS webhook_url, posts.title AS post_title
+FROM webhook_deliveries
+INNER JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id
+LEFT JOIN posts ON posts.id = webhook_deliveries.post_id
+WHERE webhooks.user_id = $1
+ORDER BY webhook_deliveries.created_at DESC
+LIMIT $2;
@@ -10,5 +10,4 @@ CREATE TABLE feeds(
 );
 
 -- +goose Down
-DROP CONSTRAINT users_id_fkey;
 DROP TABLE feeds;
@@ -0,0 +1,14 @@
+-- +goose Up
+
+CREATE TABLE feed_url_history(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    old_url TEXT NOT NULL,
+    new_url TEXT NOT NULL,
+    reason TEXT NOT NULL
+);
+
+-- +goose Down
+
+DROP TABLE feed_url_history;
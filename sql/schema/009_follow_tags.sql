@@ -0,0 +1,14 @@
+-- +goose Up
+
+CREATE TABLE follow_tags(
+    feed_follow_id UUID NOT NULL REFERENCES feed_follows(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (feed_follow_id, tag)
+);
+
+CREATE INDEX follow_tags_tag_idx ON follow_tags(tag);
+
+-- +goose Down
+
+DROP TABLE follow_tags;
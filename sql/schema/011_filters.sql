@@ -0,0 +1,33 @@
+-- +goose Up
+
+ALTER TABLE posts ADD COLUMN author TEXT;
+ALTER TABLE posts ADD COLUMN categories TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE TABLE post_states(
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    read_at TIMESTAMPTZ,
+    saved_at TIMESTAMPTZ,
+    PRIMARY KEY (user_id, post_id)
+);
+
+CREATE TABLE filter_rules(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    field TEXT NOT NULL,
+    pattern TEXT NOT NULL,
+    is_regex BOOLEAN NOT NULL DEFAULT false,
+    action TEXT NOT NULL,
+    feed_id UUID REFERENCES feeds(id) ON DELETE CASCADE,
+    tag TEXT
+);
+
+CREATE INDEX filter_rules_user_id_idx ON filter_rules(user_id);
+
+-- +goose Down
+
+DROP TABLE filter_rules;
+DROP TABLE post_states;
+ALTER TABLE posts DROP COLUMN categories;
+ALTER TABLE posts DROP COLUMN author;
@@ -0,0 +1,32 @@
+-- +goose Up
+
+CREATE TABLE webhooks(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    feed_id UUID REFERENCES feeds(id) ON DELETE CASCADE,
+    tag TEXT,
+    keywords TEXT,
+    payload_template TEXT
+);
+
+CREATE INDEX webhooks_user_id_idx ON webhooks(user_id);
+
+CREATE TABLE webhook_deliveries(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    webhook_id UUID NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    post_id UUID REFERENCES posts(id) ON DELETE SET NULL,
+    attempts INTEGER NOT NULL,
+    status_code INTEGER,
+    error TEXT
+);
+
+CREATE INDEX webhook_deliveries_webhook_id_created_at_idx ON webhook_deliveries(webhook_id, created_at);
+
+-- +goose Down
+
+DROP TABLE webhook_deliveries;
+DROP TABLE webhooks;
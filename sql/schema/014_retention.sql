@@ -0,0 +1,9 @@
+-- +goose Up
+
+ALTER TABLE feeds ADD COLUMN retention_days INTEGER;
+ALTER TABLE feeds ADD COLUMN retention_posts INTEGER;
+
+-- +goose Down
+
+ALTER TABLE feeds DROP COLUMN retention_posts;
+ALTER TABLE feeds DROP COLUMN retention_days;
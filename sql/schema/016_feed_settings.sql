@@ -0,0 +1,9 @@
+-- +goose Up
+
+ALTER TABLE feeds ADD COLUMN fetch_interval_seconds INTEGER;
+ALTER TABLE feeds ADD COLUMN enabled BOOLEAN NOT NULL DEFAULT true;
+
+-- +goose Down
+
+ALTER TABLE feeds DROP COLUMN enabled;
+ALTER TABLE feeds DROP COLUMN fetch_interval_seconds;
@@ -0,0 +1,13 @@
+-- +goose Up
+
+CREATE TABLE pruned_posts(
+    url TEXT PRIMARY KEY,
+    feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    pruned_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX pruned_posts_feed_id_idx ON pruned_posts(feed_id);
+
+-- +goose Down
+
+DROP TABLE pruned_posts;
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eefret/gator/internal/database"
+	"github.com/eefret/gator/internal/database/sqlite"
+	"github.com/eefret/gator/internal/migrate"
+)
+
+// openStore opens the database named by db_url. sqlite: URLs name a SQLite
+// file (sqlite:///var/lib/gator.db, sqlite://gator.db relative to the working
+// directory, or sqlite://~/gator.db); anything else is passed to the
+// PostgreSQL driver. It also returns the dialect migrations run in.
+func openStore(dbURL string) (database.Store, migrate.Dialect, error) {
+	if path, ok := sqlitePath(dbURL); ok {
+		if path == "" {
+			return nil, migrate.Dialect{}, fmt.Errorf("db_url %q has no file path", dbURL)
+		}
+		store, err := sqlite.Open(path)
+		if err != nil {
+			return nil, migrate.Dialect{}, err
+		}
+		return store, sqlite.Dialect, nil
+	}
+
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		return nil, migrate.Dialect{}, err
+	}
+	return database.NewPostgresStore(db), migrate.Postgres, nil
+}
+
+// sqlitePath returns the file path in a sqlite: URL, expanding a leading ~
+// to the home directory.
+func sqlitePath(dbURL string) (string, bool) {
+	rest, ok := strings.CutPrefix(dbURL, "sqlite:")
+	if !ok {
+		return "", false
+	}
+	rest = strings.TrimPrefix(rest, "//")
+	if home, err := os.UserHomeDir(); err == nil {
+		if tail, ok := strings.CutPrefix(rest, "~/"); ok {
+			rest = filepath.Join(home, tail)
+		}
+	}
+	return rest, true
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/eefret/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+// validTag restricts tags to short, shell-friendly names such as "go" or
+// "security-news".
+var validTag = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,63}$`)
+
+// normalizeTag lowercases and validates a tag name.
+func normalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if !validTag.MatchString(tag) {
+		return "", fmt.Errorf("Invalid tag %q: use up to 64 letters, digits, '-', '_' or '.'", tag)
+	}
+	return tag, nil
+}
+
+// handleTag adds a tag to one of the user's follows: tag <url> <tag>.
+func handleTag(s *State, cmd Command, user database.User) error {
+	if len(cmd.Arguments) != 2 {
+		return fmt.Errorf("usage: %s <url> <tag>", cmd.Name)
+	}
+
+	tag, err := normalizeTag(cmd.Arguments[1])
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	follow, err := getFollow(ctx, s, user, cmd.Arguments[0])
+	if err != nil {
+		return err
+	}
+
+	err = s.db.CreateFollowTag(ctx, database.CreateFollowTagParams{
+		FeedFollowID: follow.ID,
+		Tag:          tag,
+	})
+	if err != nil {
+		return fmt.Errorf("Error tagging feed: %v", err)
+	}
+
+	fmt.Printf("Tagged %s with %s\n", cmd.Arguments[0], tag)
+	return nil
+}
+
+// handleUntag removes a tag from one of the user's follows: untag <url> <tag>.
+func handleUntag(s *State, cmd Command, user database.User) error {
+	if len(cmd.Arguments) != 2 {
+		return fmt.Errorf("usage: %s <url> <tag>", cmd.Name)
+	}
+
+	tag, err := normalizeTag(cmd.Arguments[1])
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	follow, err := getFollow(ctx, s, user, cmd.Arguments[0])
+	if err != nil {
+		return err
+	}
+
+	removed, err := s.db.DeleteFollowTag(ctx, database.DeleteFollowTagParams{
+		FeedFollowID: follow.ID,
+		Tag:          tag,
+	})
+	if err != nil {
+		return fmt.Errorf("Error untagging feed: %v", err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("%s is not tagged %s", cmd.Arguments[0], tag)
+	}
+
+	fmt.Printf("Removed tag %s from %s\n", tag, cmd.Arguments[0])
+	return nil
+}
+
+// getFollow returns the user's follow of the feed ref refers to; see
+// resolveFollowedFeed.
+func getFollow(ctx context.Context, s *State, user database.User, ref string) (database.FeedFollow, error) {
+	followed, err := resolveFollowedFeed(ctx, s, user, ref)
+	if err != nil {
+		return database.FeedFollow{}, err
+	}
+
+	follow, err := s.db.GetFeedFollowForUser(ctx, database.GetFeedFollowForUserParams{
+		UserID: user.ID,
+		Url:    followed.FeedUrl,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return follow, fmt.Errorf("%s is not following %s", user.Name, ref)
+	}
+	if err != nil {
+		return follow, fmt.Errorf("Error getting follow: %v", err)
+	}
+	return follow, nil
+}
+
+// followTags maps each feed the user follows to its tags, sorted by name.
+func followTags(ctx context.Context, s *State, user database.User) (map[uuid.UUID][]string, error) {
+	rows, err := s.db.GetFollowTagsForUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting tags: %v", err)
+	}
+
+	tags := make(map[uuid.UUID][]string)
+	for _, row := range rows {
+		tags[row.FeedID] = append(tags[row.FeedID], row.Tag)
+	}
+	return tags, nil
+}
+
+// parseTagFlag removes "--tag <name>" from args and returns the normalized
+// tag, or "" when the flag is absent.
+func parseTagFlag(args []string) ([]string, string, error) {
+	var rest []string
+	tag := ""
+	for i := 0; i < len(args); i++ {
+		if args[i] != "--tag" {
+			rest = append(rest, args[i])
+			continue
+		}
+		if i+1 >= len(args) {
+			return nil, "", fmt.Errorf("--tag requires a value")
+		}
+		i++
+		normalized, err := normalizeTag(args[i])
+		if err != nil {
+			return nil, "", err
+		}
+		tag = normalized
+	}
+	return rest, tag, nil
+}
@@ -0,0 +1,557 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/eefret/gator/internal/database"
+	"github.com/eefret/gator/internal/filter"
+	"github.com/eefret/gator/internal/htmltext"
+	"github.com/eefret/gator/internal/output"
+	"github.com/eefret/gator/internal/webhook"
+	"github.com/google/uuid"
+)
+
+// webhookTimeout bounds the deliveries for one scrape, retries included.
+const webhookTimeout = time.Minute
+
+// webhookRow is a webhook as listed by webhooks list. The secret is left out.
+type webhookRow struct {
+	ID       uuid.UUID `json:"id"`
+	URL      string    `json:"url"`
+	Scope    string    `json:"scope"`
+	Keywords string    `json:"keywords"`
+	Template bool      `json:"template"`
+}
+
+// handleWebhooks dispatches the webhooks command family:
+//
+//	webhooks [list]
+//	webhooks add <url> [--feed <url> | --tag <tag>] [--keywords <k1,k2>] [--template-file <path>] [--secret <secret>]
+//	webhooks remove <id>
+//	webhooks test <id>
+//	webhooks deliveries [--limit N]
+func handleWebhooks(s *State, cmd Command, user database.User) error {
+	sub, args := "list", cmd.Arguments
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+
+	switch sub {
+	case "list":
+		return listWebhooks(s, user)
+	case "add":
+		return addWebhook(s, user, args)
+	case "remove":
+		return removeWebhook(s, user, args)
+	case "test":
+		return testWebhook(s, user, args)
+	case "deliveries":
+		return listWebhookDeliveries(s, user, args)
+	}
+	return fmt.Errorf("usage: %s [list|add|remove|test|deliveries]", cmd.Name)
+}
+
+func listWebhooks(s *State, user database.User) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	hooks, err := s.db.GetWebhooksForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("Error getting webhooks: %v", err)
+	}
+
+	rows := []webhookRow{}
+	for _, hook := range hooks {
+		scope := "all feeds"
+		switch {
+		case hook.FeedUrl.Valid:
+			scope = "feed " + hook.FeedUrl.String
+		case hook.Tag.Valid:
+			scope = "tag " + hook.Tag.String
+		}
+		rows = append(rows, webhookRow{
+			ID:       hook.ID,
+			URL:      hook.Url,
+			Scope:    scope,
+			Keywords: hook.Keywords.String,
+			Template: hook.PayloadTemplate.Valid,
+		})
+	}
+
+	if s.Output != output.FormatText {
+		return output.Write(os.Stdout, s.Output, rows)
+	}
+
+	if len(rows) == 0 {
+		fmt.Println("No webhooks")
+		return nil
+	}
+	for _, row := range rows {
+		fmt.Printf("%s  %s (%s)", shortID(row.ID), row.URL, row.Scope)
+		if row.Keywords != "" {
+			fmt.Printf(" keywords %q", row.Keywords)
+		}
+		if row.Template {
+			fmt.Print(" with template")
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+func addWebhook(s *State, user database.User, args []string) error {
+	usage := fmt.Errorf("usage: webhooks add <url> [--feed <url> | --tag <tag>] [--keywords <k1,k2>] [--template-file <path>] [--secret <secret>]")
+
+	var positional []string
+	flags := map[string]string{}
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--feed", "--tag", "--keywords", "--template-file", "--secret":
+			if i+1 >= len(args) {
+				return fmt.Errorf("%s requires a value", args[i])
+			}
+			flags[args[i]] = args[i+1]
+			i++
+		default:
+			positional = append(positional, args[i])
+		}
+	}
+	if len(positional) != 1 || (flags["--feed"] != "" && flags["--tag"] != "") {
+		return usage
+	}
+
+	target, err := url.Parse(positional[0])
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("Invalid webhook URL %q", positional[0])
+	}
+
+	params := database.CreateWebhookParams{
+		UserID: user.ID,
+		Url:    target.String(),
+		Secret: flags["--secret"],
+	}
+
+	if tag := flags["--tag"]; tag != "" {
+		normalized, err := normalizeTag(tag)
+		if err != nil {
+			return err
+		}
+		params.Tag = sql.NullString{String: normalized, Valid: true}
+	}
+	if keywords := flags["--keywords"]; keywords != "" {
+		if _, err := filter.Compile(filter.Rule{Field: filter.FieldAny, Pattern: keywords}); err != nil {
+			return err
+		}
+		params.Keywords = sql.NullString{String: keywords, Valid: true}
+	}
+	if path := flags["--template-file"]; path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("Error reading template: %v", err)
+		}
+		if _, err := webhook.ParseTemplate(string(data)); err != nil {
+			return err
+		}
+		params.PayloadTemplate = sql.NullString{String: string(data), Valid: true}
+	}
+	if params.Secret == "" {
+		params.Secret, err = webhook.NewSecret()
+		if err != nil {
+			return fmt.Errorf("Error generating secret: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if feedURL := flags["--feed"]; feedURL != "" {
+		follow, err := getFollow(ctx, s, user, feedURL)
+		if err != nil {
+			return err
+		}
+		params.FeedID = uuid.NullUUID{UUID: follow.FeedID, Valid: true}
+	}
+
+	hook, err := s.db.CreateWebhook(ctx, params)
+	if err != nil {
+		return fmt.Errorf("Error creating webhook: %v", err)
+	}
+
+	fmt.Printf("Added webhook %s for %s\n", shortID(hook.ID), hook.Url)
+	fmt.Printf("Signing secret: %s\n", hook.Secret)
+	fmt.Printf("Requests carry %s: sha256=HMAC-SHA256(secret, %s + \".\" + body)\n", webhook.HeaderSignature, webhook.HeaderTimestamp)
+	return nil
+}
+
+func removeWebhook(s *State, user database.User, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: webhooks remove <id>")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	hook, err := findWebhook(ctx, s, user, args[0])
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.db.DeleteWebhook(ctx, database.DeleteWebhookParams{UserID: user.ID, ID: hook.ID}); err != nil {
+		return fmt.Errorf("Error removing webhook: %v", err)
+	}
+
+	fmt.Printf("Removed webhook %s\n", shortID(hook.ID))
+	return nil
+}
+
+// testWebhook sends a sample delivery so receivers can be checked before
+// real posts arrive.
+func testWebhook(s *State, user database.User, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: webhooks test <id>")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+
+	hook, err := findWebhook(ctx, s, user, args[0])
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	payload := webhook.Payload{
+		Event: "ping",
+		Feed:  webhook.Feed{Name: "gator", URL: "https://example.com/feed.xml"},
+		Post: webhook.Post{
+			Title:       "Test delivery from gator",
+			URL:         "https://example.com/test",
+			PublishedAt: &now,
+		},
+	}
+
+	result := deliverWebhook(ctx, s.db, hook.ID, hook.Url, hook.Secret, hook.PayloadTemplate.String, payload, uuid.NullUUID{})
+	if result.Err != nil {
+		return fmt.Errorf("Delivery failed after %d attempts: %v", result.Attempts, result.Err)
+	}
+
+	fmt.Printf("Delivered to %s (HTTP %d)\n", hook.Url, result.StatusCode)
+	return nil
+}
+
+func listWebhookDeliveries(s *State, user database.User, args []string) error {
+	limit := 20
+	for i := 0; i < len(args); i++ {
+		if args[i] != "--limit" || i+1 >= len(args) {
+			return fmt.Errorf("usage: webhooks deliveries [--limit N]")
+		}
+		i++
+		n, err := strconv.Atoi(args[i])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid limit: %s", args[i])
+		}
+		limit = n
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	deliveries, err := s.db.GetWebhookDeliveriesForUser(ctx, database.GetWebhookDeliveriesForUserParams{
+		UserID: user.ID,
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		return fmt.Errorf("Error getting deliveries: %v", err)
+	}
+
+	if s.Output != output.FormatText {
+		return output.Write(os.Stdout, s.Output, deliveries)
+	}
+
+	for _, d := range deliveries {
+		status := "-"
+		if d.StatusCode.Valid {
+			status = strconv.Itoa(int(d.StatusCode.Int32))
+		}
+		title := d.PostTitle.String
+		if !d.PostID.Valid {
+			title = "(test)"
+		}
+		fmt.Printf("%s  %s  %s  attempts=%d  %s", d.CreatedAt.Local().Format(time.DateTime), d.WebhookUrl, status, d.Attempts, title)
+		if d.Error.Valid {
+			fmt.Printf("  error: %s", d.Error.String)
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+func findWebhook(ctx context.Context, s *State, user database.User, prefix string) (database.GetWebhooksForUserRow, error) {
+	hooks, err := s.db.GetWebhooksForUser(ctx, user.ID)
+	if err != nil {
+		return database.GetWebhooksForUserRow{}, fmt.Errorf("Error getting webhooks: %v", err)
+	}
+
+	ids := make([]uuid.UUID, 0, len(hooks))
+	for _, hook := range hooks {
+		ids = append(ids, hook.ID)
+	}
+	id, err := resolveIDPrefix("webhook", prefix, ids)
+	if err != nil {
+		return database.GetWebhooksForUserRow{}, err
+	}
+
+	for _, hook := range hooks {
+		if hook.ID == id {
+			return hook, nil
+		}
+	}
+	return database.GetWebhooksForUserRow{}, fmt.Errorf("No webhook %s", prefix)
+}
+
+// webhookQueueSize is how many scrapes' worth of posts may wait for delivery
+// in the aggregator before further ones are dropped.
+const webhookQueueSize = 64
+
+// webhookQueue delivers webhooks for new posts on a background worker, so a
+// slow endpoint never holds up fetching.
+type webhookQueue struct {
+	db   database.Store
+	jobs chan webhookJob
+	done chan struct{}
+}
+
+// webhookJob is the posts one scrape inserted into feed.
+type webhookJob struct {
+	feed  database.Feed
+	posts []database.Post
+}
+
+// newWebhookQueue starts a queue holding up to size scrapes, whose deliveries
+// stop when ctx is cancelled.
+func newWebhookQueue(ctx context.Context, db database.Store, size int) *webhookQueue {
+	q := &webhookQueue{
+		db:   db,
+		jobs: make(chan webhookJob, size),
+		done: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(q.done)
+		for job := range q.jobs {
+			if ctx.Err() != nil {
+				dropWebhooks(q.db, job.feed, job.posts, "shutting down")
+				continue
+			}
+			notifyWebhooks(ctx, q.db, job.feed, job.posts)
+		}
+	}()
+
+	return q
+}
+
+// enqueue schedules delivery of posts without blocking. When the queue is
+// full the deliveries are dropped and recorded as not sent.
+func (q *webhookQueue) enqueue(feed database.Feed, posts []database.Post) {
+	select {
+	case q.jobs <- webhookJob{feed: feed, posts: posts}:
+	default:
+		dropWebhooks(q.db, feed, posts, "webhook queue full")
+	}
+}
+
+// close waits for queued deliveries to finish. Nothing may be enqueued after.
+func (q *webhookQueue) close() {
+	close(q.jobs)
+	<-q.done
+}
+
+// webhookDelivery is a post due at one webhook.
+type webhookDelivery struct {
+	hook    database.GetWebhooksForFeedRow
+	post    database.Post
+	payload webhook.Payload
+}
+
+// notifyWebhooks delivers posts, newly inserted into feed, to every webhook
+// due them. Deliveries left when the time budget runs out, or ctx is
+// cancelled, are recorded as not sent.
+func notifyWebhooks(ctx context.Context, db database.Store, feed database.Feed, posts []database.Post) {
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	deliveries, err := matchWebhooks(ctx, db, feed, posts)
+	if err != nil {
+		slog.Warn("Error getting webhooks", append(feedAttrs(feed), "error", err)...)
+		return
+	}
+
+	for i, d := range deliveries {
+		if ctx.Err() != nil {
+			reason := "shutting down"
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				reason = fmt.Sprintf("webhook time budget of %s exceeded", webhookTimeout)
+			}
+			recordSkippedDeliveries(db, feed, deliveries[i:], reason)
+			return
+		}
+
+		result := deliverWebhook(ctx, db, d.hook.ID, d.hook.Url, d.hook.Secret, d.hook.PayloadTemplate.String, d.payload, uuid.NullUUID{UUID: d.post.ID, Valid: true})
+		if result.Err != nil {
+			slog.Warn("Webhook delivery failed", "webhook_id", d.hook.ID, "url", d.hook.Url, "post_url", d.post.Url, "attempts", result.Attempts, "error", result.Err)
+		}
+	}
+}
+
+// dropWebhooks records the deliveries posts were due as not sent, for posts
+// that never reached the delivery worker.
+func dropWebhooks(db database.Store, feed database.Feed, posts []database.Post, reason string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	deliveries, err := matchWebhooks(ctx, db, feed, posts)
+	if err != nil {
+		slog.Warn("Error getting webhooks", append(feedAttrs(feed), "error", err)...)
+		return
+	}
+	recordSkippedDeliveries(db, feed, deliveries, reason)
+}
+
+// recordSkippedDeliveries logs deliveries that were never attempted, with no
+// attempts and reason as their error, so the delivery log shows every post a
+// webhook missed.
+func recordSkippedDeliveries(db database.Store, feed database.Feed, deliveries []webhookDelivery, reason string) {
+	if len(deliveries) == 0 {
+		return
+	}
+	slog.Warn("Webhook deliveries not sent", append(feedAttrs(feed), "deliveries", len(deliveries), "reason", reason)...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, d := range deliveries {
+		err := db.CreateWebhookDelivery(ctx, database.CreateWebhookDeliveryParams{
+			WebhookID: d.hook.ID,
+			PostID:    uuid.NullUUID{UUID: d.post.ID, Valid: true},
+			Error:     sql.NullString{String: "not sent: " + reason, Valid: true},
+		})
+		if err != nil {
+			slog.Warn("Error recording webhook delivery", "webhook_id", d.hook.ID, "error", err)
+			return
+		}
+	}
+}
+
+// matchWebhooks returns the deliveries posts, newly inserted into feed, are
+// due: one per post for every webhook whose owner follows the feed and whose
+// scope and keywords match.
+func matchWebhooks(ctx context.Context, db database.Store, feed database.Feed, posts []database.Post) ([]webhookDelivery, error) {
+	hooks, err := db.GetWebhooksForFeed(ctx, feed.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries []webhookDelivery
+	for _, hook := range hooks {
+		var keywords *filter.Matcher
+		if hook.Keywords.Valid {
+			keywords, err = filter.Compile(filter.Rule{Field: filter.FieldAny, Pattern: hook.Keywords.String})
+			if err != nil {
+				slog.Warn("Skipping webhook with invalid keywords", "webhook_id", hook.ID, "error", err)
+				continue
+			}
+		}
+
+		feedName := feed.Name
+		if hook.FollowDisplayName.Valid {
+			feedName = hook.FollowDisplayName.String
+		}
+
+		for _, post := range posts {
+			description := htmltext.Render(post.Description.String, 1<<20)
+			if keywords != nil && !keywords.Match(filter.Post{
+				FeedID:      post.FeedID,
+				Title:       post.Title,
+				Description: description,
+				Author:      post.Author.String,
+				Categories:  post.Categories,
+			}, nil) {
+				continue
+			}
+
+			payload := webhook.Payload{
+				Event: webhook.EventPostCreated,
+				Feed:  webhook.Feed{ID: feed.ID.String(), Name: feedName, URL: feed.Url},
+				Post: webhook.Post{
+					ID:          post.ID.String(),
+					Title:       post.Title,
+					URL:         post.Url,
+					Description: description,
+					Author:      post.Author.String,
+					Categories:  post.Categories,
+				},
+			}
+			if post.PublishedAt.Valid {
+				payload.Post.PublishedAt = &post.PublishedAt.Time
+			}
+
+			deliveries = append(deliveries, webhookDelivery{hook: hook, post: post, payload: payload})
+		}
+	}
+	return deliveries, nil
+}
+
+// deliverWebhook renders and sends payload, then records the outcome in the
+// delivery log.
+func deliverWebhook(ctx context.Context, db database.Store, hookID uuid.UUID, hookURL, secret, template string, payload webhook.Payload, postID uuid.NullUUID) webhook.Result {
+	var result webhook.Result
+
+	tmpl, err := webhook.ParseTemplate(template)
+	if err == nil {
+		var body []byte
+		var contentType string
+		body, contentType, err = tmpl.Render(payload)
+		if err == nil {
+			result = webhook.DefaultSender.Send(ctx, webhook.Request{
+				URL:         hookURL,
+				Secret:      secret,
+				DeliveryID:  uuid.NewString(),
+				Event:       payload.Event,
+				Body:        body,
+				ContentType: contentType,
+			})
+		}
+	}
+	if err != nil {
+		result.Err = err
+	}
+
+	// Log deliveries cut short by shutdown too.
+	logCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	params := database.CreateWebhookDeliveryParams{
+		WebhookID: hookID,
+		PostID:    postID,
+		Attempts:  int32(result.Attempts),
+	}
+	if result.StatusCode != 0 {
+		params.StatusCode = sql.NullInt32{Int32: int32(result.StatusCode), Valid: true}
+	}
+	if result.Err != nil {
+		params.Error = sql.NullString{String: result.Err.Error(), Valid: true}
+	}
+	if err := db.CreateWebhookDelivery(logCtx, params); err != nil {
+		slog.Warn("Error recording webhook delivery", "webhook_id", hookID, "error", err)
+	}
+
+	return result
+}